@@ -0,0 +1,224 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	v2 "github.com/argoproj-labs/argo-cd-tokens/api/v2"
+)
+
+// ConvertTo converts this Token to the v2 hub version
+func (src *Token) ConvertTo(dstRaw conversion.Hub) error {
+	dst, ok := dstRaw.(*v2.Token)
+	if !ok {
+		return fmt.Errorf("cannot convert a v1 Token to %T", dstRaw)
+	}
+
+	dst.ObjectMeta = src.ObjectMeta
+
+	dst.Spec.ArgoCD = v2.ArgoCDSpec{
+		Server:         src.Spec.ArgoCDEndpt,
+		Protocol:       src.Spec.Protocol,
+		CredentialsRef: src.Spec.CredentialsRef,
+	}
+	for _, instance := range src.Spec.Instances {
+		dst.Spec.ArgoCD.Instances = append(dst.Spec.ArgoCD.Instances, v2.ArgoCDInstance{
+			Name:   instance.Name,
+			Server: instance.ArgoCDEndpt,
+			Key:    instance.Key,
+		})
+	}
+	dst.Spec.Project = src.Spec.Project
+	dst.Spec.Role = src.Spec.Role
+	dst.Spec.Expiry = v2.ExpirySpec{}
+	if src.Spec.ExpiresIn != nil {
+		dst.Spec.Expiry.Duration = &metav1.Duration{Duration: time.Duration(*src.Spec.ExpiresIn) * time.Second}
+	}
+	dst.Spec.Rotation = v2.RotationSpec{
+		Schedule: convertScheduleTo(src.Spec.RotationSchedule),
+	}
+	dst.Spec.Output = v2.OutputSpec{
+		Secret: v2.SecretReference(src.Spec.SecretRef),
+	}
+	for _, target := range src.Spec.RolloutTargets {
+		dst.Spec.Output.RolloutTargets = append(dst.Spec.Output.RolloutTargets, v2.RolloutTarget(target))
+	}
+	dst.Spec.Suspend = src.Spec.Suspend
+	dst.Spec.DryRun = src.Spec.DryRun
+
+	dst.Status = v2.TokenStatus{
+		Conditions:     convertConditionsTo(src.Status.Conditions),
+		LastRotateAt:   src.Status.LastRotateAt,
+		NextRotationAt: src.Status.NextRotationAt,
+		PlannedActions: src.Status.PlannedActions,
+	}
+	if effective := src.Status.Effective; effective != nil {
+		dst.Status.Effective = &v2.TokenSettings{
+			Server:           effective.ArgoCDEndpt,
+			Protocol:         effective.Protocol,
+			SecretKey:        effective.SecretKey,
+			RotationSchedule: convertScheduleTo(effective.RotationSchedule),
+			SecretLabels:     effective.SecretLabels,
+			RenewPercent:     effective.RenewPercent,
+		}
+		if effective.ExpiresIn != 0 {
+			dst.Status.Effective.Expiry = &metav1.Duration{Duration: time.Duration(effective.ExpiresIn) * time.Second}
+		}
+	}
+	for _, instance := range src.Status.Instances {
+		dst.Status.Instances = append(dst.Status.Instances, v2.InstanceStatus{
+			Name:           instance.Name,
+			Server:         instance.ArgoCDEndpt,
+			Key:            instance.Key,
+			Conditions:     convertConditionsTo(instance.Conditions),
+			LastRotateAt:   instance.LastRotateAt,
+			NextRotationAt: instance.NextRotationAt,
+			PlannedActions: instance.PlannedActions,
+		})
+	}
+	return nil
+}
+
+// ConvertFrom converts the v2 hub version to this Token. Expiry durations are rounded down to whole seconds.
+func (dst *Token) ConvertFrom(srcRaw conversion.Hub) error {
+	src, ok := srcRaw.(*v2.Token)
+	if !ok {
+		return fmt.Errorf("cannot convert %T to a v1 Token", srcRaw)
+	}
+
+	dst.ObjectMeta = src.ObjectMeta
+
+	dst.Spec = TokenSpec{
+		Project:          src.Spec.Project,
+		Role:             src.Spec.Role,
+		ArgoCDEndpt:      src.Spec.ArgoCD.Server,
+		SecretRef:        SecretReference(src.Spec.Output.Secret),
+		CredentialsRef:   src.Spec.ArgoCD.CredentialsRef,
+		Protocol:         src.Spec.ArgoCD.Protocol,
+		RotationSchedule: convertScheduleFrom(src.Spec.Rotation.Schedule),
+		Suspend:          src.Spec.Suspend,
+		DryRun:           src.Spec.DryRun,
+	}
+	if src.Spec.Expiry.Duration != nil {
+		expiresIn := int(src.Spec.Expiry.Duration.Duration / time.Second)
+		dst.Spec.ExpiresIn = &expiresIn
+	}
+	for _, target := range src.Spec.Output.RolloutTargets {
+		dst.Spec.RolloutTargets = append(dst.Spec.RolloutTargets, RolloutTarget(target))
+	}
+	for _, instance := range src.Spec.ArgoCD.Instances {
+		dst.Spec.Instances = append(dst.Spec.Instances, ArgoCDInstance{
+			Name:        instance.Name,
+			ArgoCDEndpt: instance.Server,
+			Key:         instance.Key,
+		})
+	}
+
+	dst.Status = TokenStatus{
+		Conditions:     convertConditionsFrom(src.Status.Conditions),
+		LastRotateAt:   src.Status.LastRotateAt,
+		NextRotationAt: src.Status.NextRotationAt,
+		PlannedActions: src.Status.PlannedActions,
+	}
+	if effective := src.Status.Effective; effective != nil {
+		dst.Status.Effective = &TokenSettings{
+			ArgoCDEndpt:      effective.Server,
+			Protocol:         effective.Protocol,
+			SecretKey:        effective.SecretKey,
+			RotationSchedule: convertScheduleFrom(effective.RotationSchedule),
+			SecretLabels:     effective.SecretLabels,
+			RenewPercent:     effective.RenewPercent,
+		}
+		if effective.Expiry != nil {
+			dst.Status.Effective.ExpiresIn = int(effective.Expiry.Duration / time.Second)
+		}
+	}
+	for _, instance := range src.Status.Instances {
+		dst.Status.Instances = append(dst.Status.Instances, InstanceStatus{
+			Name:           instance.Name,
+			ArgoCDEndpt:    instance.Server,
+			Key:            instance.Key,
+			Conditions:     convertConditionsFrom(instance.Conditions),
+			LastRotateAt:   instance.LastRotateAt,
+			NextRotationAt: instance.NextRotationAt,
+			PlannedActions: instance.PlannedActions,
+		})
+	}
+	return nil
+}
+
+func convertScheduleTo(schedule *RotationSchedule) *v2.RotationSchedule {
+	if schedule == nil {
+		return nil
+	}
+	converted := &v2.RotationSchedule{
+		Cron:     schedule.Cron,
+		TimeZone: schedule.TimeZone,
+		Window:   schedule.Window,
+	}
+	for _, blackout := range schedule.Blackouts {
+		converted.Blackouts = append(converted.Blackouts, v2.BlackoutWindow(blackout))
+	}
+	return converted
+}
+
+func convertScheduleFrom(schedule *v2.RotationSchedule) *RotationSchedule {
+	if schedule == nil {
+		return nil
+	}
+	converted := &RotationSchedule{
+		Cron:     schedule.Cron,
+		TimeZone: schedule.TimeZone,
+		Window:   schedule.Window,
+	}
+	for _, blackout := range schedule.Blackouts {
+		converted.Blackouts = append(converted.Blackouts, BlackoutWindow(blackout))
+	}
+	return converted
+}
+
+func convertConditionsTo(conditions []TokenCondition) []v2.TokenCondition {
+	var converted []v2.TokenCondition
+	for _, condition := range conditions {
+		converted = append(converted, v2.TokenCondition{
+			Type:               v2.TokenConditionType(condition.Type),
+			Status:             condition.Status,
+			LastTransitionTime: condition.LastTransitionTime,
+			Reason:             condition.Reason,
+			Message:            condition.Message,
+		})
+	}
+	return converted
+}
+
+func convertConditionsFrom(conditions []v2.TokenCondition) []TokenCondition {
+	var converted []TokenCondition
+	for _, condition := range conditions {
+		converted = append(converted, TokenCondition{
+			Type:               TokenConditionType(condition.Type),
+			Status:             condition.Status,
+			LastTransitionTime: condition.LastTransitionTime,
+			Reason:             condition.Reason,
+			Message:            condition.Message,
+		})
+	}
+	return converted
+}
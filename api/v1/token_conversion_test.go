@@ -0,0 +1,166 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v2 "github.com/argoproj-labs/argo-cd-tokens/api/v2"
+)
+
+var _ = Describe("Token conversion", func() {
+	It("round-trips a v1 Token through v2", func() {
+		expiresIn := 3600
+		original := &Token{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"},
+			Spec: TokenSpec{
+				Project:     "default",
+				Role:        "ci",
+				ArgoCDEndpt: "https://argocd.example.com",
+				ExpiresIn:   &expiresIn,
+				SecretRef:   SecretReference{Name: "ci-token", Key: "token"},
+				CredentialsRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "team-credential"},
+					Key:                  "token",
+				},
+				Protocol: "grpc",
+				Instances: []ArgoCDInstance{
+					{Name: "eu", ArgoCDEndpt: "https://eu.argocd.example.com"},
+				},
+				RotationSchedule: &RotationSchedule{
+					Cron:      "0 2 * * *",
+					Blackouts: []BlackoutWindow{{Cron: "0 0 * * 5", Duration: 3600}},
+				},
+				RolloutTargets: []RolloutTarget{{Kind: "Deployment", Name: "ci"}},
+				DryRun:         true,
+			},
+			Status: TokenStatus{
+				Conditions:     []TokenCondition{{Type: "Ready", Status: corev1.ConditionTrue, Reason: "Issued"}},
+				NextRotationAt: "2020-01-01T02:00:00Z",
+				Instances:      []InstanceStatus{{Name: "eu", ArgoCDEndpt: "https://eu.argocd.example.com", Key: "token-eu", LastRotateAt: "1"}},
+				Effective: &TokenSettings{
+					ArgoCDEndpt:      "https://argocd.example.com",
+					ExpiresIn:        3600,
+					SecretKey:        "token",
+					RotationSchedule: &RotationSchedule{Cron: "0 2 * * *"},
+					SecretLabels:     map[string]string{"team": "ci"},
+				},
+			},
+		}
+
+		hub := &v2.Token{}
+		Expect(original.ConvertTo(hub)).To(Succeed())
+		Expect(hub.Spec.ArgoCD.Server).To(Equal("https://argocd.example.com"))
+		Expect(hub.Spec.Expiry.Duration.Duration).To(Equal(time.Hour))
+		Expect(hub.Spec.Output.Secret.Name).To(Equal("ci-token"))
+
+		converted := &Token{}
+		Expect(converted.ConvertFrom(hub)).To(Succeed())
+		Expect(converted).To(Equal(original))
+	})
+
+	It("round-trips a v2 Token through v1", func() {
+		original := &v2.Token{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default", Annotations: map[string]string{RotateAtAnnotation: "now"}},
+			Spec: v2.TokenSpec{
+				ArgoCD: v2.ArgoCDSpec{
+					Server:   "https://argocd.example.com",
+					Protocol: "grpc",
+					CredentialsRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "team-credential"},
+						Key:                  "token",
+					},
+					Instances: []v2.ArgoCDInstance{{Name: "eu", Server: "https://eu.argocd.example.com", Key: "token-eu"}},
+				},
+				Project: "default",
+				Role:    "ci",
+				Expiry:  v2.ExpirySpec{Duration: &metav1.Duration{Duration: time.Hour}},
+				Rotation: v2.RotationSpec{Schedule: &v2.RotationSchedule{
+					Cron:      "0 2 * * *",
+					TimeZone:  "Europe/Paris",
+					Window:    600,
+					Blackouts: []v2.BlackoutWindow{{Cron: "0 0 * * 5", Duration: 3600}},
+				}},
+				Output: v2.OutputSpec{
+					Secret:         v2.SecretReference{Name: "ci-token", Key: "token"},
+					RolloutTargets: []v2.RolloutTarget{{Kind: "Deployment", Name: "ci"}},
+				},
+				Suspend: true,
+			},
+			Status: v2.TokenStatus{
+				Conditions:     []v2.TokenCondition{{Type: "Ready", Status: corev1.ConditionTrue, Reason: "Issued"}},
+				LastRotateAt:   "1",
+				NextRotationAt: "2020-01-01T02:00:00Z",
+				PlannedActions: []string{"issue a token"},
+				Instances: []v2.InstanceStatus{{
+					Name:       "eu",
+					Server:     "https://eu.argocd.example.com",
+					Key:        "token-eu",
+					Conditions: []v2.TokenCondition{{Type: "Ready", Status: corev1.ConditionFalse}},
+				}},
+				Effective: &v2.TokenSettings{
+					Server:           "https://argocd.example.com",
+					Protocol:         "grpc",
+					Expiry:           &metav1.Duration{Duration: time.Hour},
+					SecretKey:        "token",
+					RotationSchedule: &v2.RotationSchedule{Cron: "0 2 * * *"},
+					SecretLabels:     map[string]string{"team": "ci"},
+				},
+			},
+		}
+
+		spoke := &Token{}
+		Expect(spoke.ConvertFrom(original)).To(Succeed())
+		Expect(spoke.Spec.ArgoCDEndpt).To(Equal("https://argocd.example.com"))
+		Expect(spoke.Spec.ExpiresInSeconds()).To(Equal(int64(3600)))
+
+		converted := &v2.Token{}
+		Expect(spoke.ConvertTo(converted)).To(Succeed())
+		Expect(converted).To(Equal(original))
+	})
+
+	It("leaves the expiry of tokens that never expire unset", func() {
+		hub := &v2.Token{}
+		Expect((&Token{}).ConvertTo(hub)).To(Succeed())
+		Expect(hub.Spec.Expiry.Duration).To(BeNil())
+	})
+
+	It("keeps tokens that never expire asked for explicitly", func() {
+		never := 0
+		hub := &v2.Token{}
+		Expect((&Token{Spec: TokenSpec{ExpiresIn: &never}}).ConvertTo(hub)).To(Succeed())
+		Expect(hub.Spec.Expiry.Duration).To(Equal(&metav1.Duration{}))
+
+		converted := &Token{}
+		Expect(converted.ConvertFrom(hub)).To(Succeed())
+		Expect(converted.Spec.ExpiresIn).To(Equal(&never))
+	})
+
+	It("rounds sub-second expiries down to whole seconds", func() {
+		hub := &v2.Token{}
+		hub.Spec.Expiry.Duration = &metav1.Duration{Duration: 90*time.Second + 500*time.Millisecond}
+
+		converted := &Token{}
+		Expect(converted.ConvertFrom(hub)).To(Succeed())
+		Expect(converted.Spec.ExpiresInSeconds()).To(Equal(int64(90)))
+	})
+})
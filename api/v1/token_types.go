@@ -16,6 +16,7 @@ limitations under the License.
 package v1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -33,17 +34,214 @@ type TokenSpec struct {
 
 	ArgoCDEndpt string `json:"argocdendpt,omitempty"`
 
-	ExpiresIn int `json:"expiresin,omitempty"`
+	// ExpiresIn is how many seconds issued tokens are valid. Left unset it takes the controller's default, 0 asks for
+	// tokens that never expire.
+	ExpiresIn *int `json:"expiresin,omitempty"`
 
 	SecretRef SecretReference `json:"secretRef,omitempty"`
+
+	// CredentialsRef selects a key of a Secret in the Token's namespace holding the Argo CD token the controller
+	// authenticates with, instead of its own credential
+	CredentialsRef *corev1.SecretKeySelector `json:"credentialsRef,omitempty"`
+
+	// Protocol selects how the controller talks to Argo CD: rest (default), grpc or grpc-web
+	// +kubebuilder:validation:Enum=rest;grpc;grpc-web
+	Protocol string `json:"protocol,omitempty"`
+
+	// RolloutTargets are restarted whenever the token in the Secret is rotated, for workloads that only
+	// read it on startup, e.g. into an environment variable
+	RolloutTargets []RolloutTarget `json:"rolloutTargets,omitempty"`
+
+	// RotationSchedule holds back rotation of an expiring token until a rotation window opens
+	RotationSchedule *RotationSchedule `json:"rotationSchedule,omitempty"`
+
+	// Suspend stops all calls to Argo CD and changes to the Secret for the Token until it is unset
+	Suspend bool `json:"suspend,omitempty"`
+
+	// DryRun makes the controller only record what it would do for the Token in its status and Events, without
+	// issuing or revoking tokens or writing the Secret
+	DryRun bool `json:"dryRun,omitempty"`
+
+	// Instances are several Argo CD instances with identically named projects to issue a token from each, instead
+	// of ArgoCDEndpt. Their tokens are stored in distinct keys of the Secret and rotated independently.
+	Instances []ArgoCDInstance `json:"instances,omitempty"`
+}
+
+// ExpiresInSeconds returns how many seconds issued tokens are valid, 0 if they never expire
+func (s TokenSpec) ExpiresInSeconds() int64 {
+	if s.ExpiresIn == nil {
+		return 0
+	}
+	return int64(*s.ExpiresIn)
+}
+
+// ArgoCDInstance is one of several Argo CD instances a Token issues tokens from
+type ArgoCDInstance struct {
+	// Name identifies the instance in the Token's status
+	// +kubebuilder:validation:Pattern=^[a-z0-9]([-a-z0-9]*[a-z0-9])?$
+	Name string `json:"name"`
+
+	ArgoCDEndpt string `json:"argocdendpt"`
+
+	// Key is the key of the Secret holding the instance's token, it defaults to the secretRef key and the name
+	// joined by a dash
+	Key string `json:"key,omitempty"`
 }
 
+// RotationSchedule restricts rotation to windows opened by a cron expression. A token is rotated in the last
+// window before it expires, or as soon as it expires if no window opens in time.
+type RotationSchedule struct {
+	// Cron is a standard five field cron expression for when rotation windows open
+	Cron string `json:"cron"`
+
+	// TimeZone is the IANA time zone Cron and Blackouts are evaluated in, it defaults to UTC
+	TimeZone string `json:"timeZone,omitempty"`
+
+	// Window is how many seconds a rotation window stays open, it defaults to an hour
+	// +kubebuilder:validation:Minimum=60
+	Window int `json:"window,omitempty"`
+
+	// Blackouts are recurring windows in which no token is rotated, even if a rotation window is open
+	Blackouts []BlackoutWindow `json:"blackouts,omitempty"`
+}
+
+// BlackoutWindow is a recurring period in which tokens aren't rotated
+type BlackoutWindow struct {
+	// Cron is a standard five field cron expression for when the blackout starts
+	Cron string `json:"cron"`
+
+	// Duration is how many seconds the blackout lasts
+	// +kubebuilder:validation:Minimum=1
+	Duration int `json:"duration"`
+}
+
+// RolloutTarget selects workloads in the Token's namespace, either by name or by label selector
+type RolloutTarget struct {
+	// +kubebuilder:validation:Enum=Deployment;StatefulSet;DaemonSet
+	Kind string `json:"kind"`
+
+	Name string `json:"name,omitempty"`
+
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+}
+
+// TokenHashAnnotation is set on the pod template of rollout targets to the hash of the current token,
+// so changing it rolls the workload
+const TokenHashAnnotation = "argoprojlabs.argoproj-labs.io/token-hash"
+
+const (
+	// ProtocolREST talks to Argo CD through its REST gateway
+	ProtocolREST = "rest"
+	// ProtocolGRPC talks to Argo CD's native gRPC API
+	ProtocolGRPC = "grpc"
+	// ProtocolGRPCWeb talks to Argo CD's gRPC API with gRPC-web, for ingresses that only pass HTTP/1.1
+	ProtocolGRPCWeb = "grpc-web"
+)
+
+// RotateAtAnnotation requests an immediate rotation of a Token's token, like kubectl rollout restart does for
+// Deployments. Its value is an RFC 3339 timestamp, which may have fractional seconds. Setting a newer one than was last
+// handled rotates again.
+const RotateAtAnnotation = "argoprojlabs.argoproj-labs.io/rotate-at"
+
 // TokenStatus defines the observed state of Token
 type TokenStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
 
 	// TokenIssuedAts []int64 `json:"tokenissuedats,omitempty"`
+
+	Conditions []TokenCondition `json:"conditions,omitempty"`
+
+	// LastRotateAt is the last rotate-at annotation value the controller rotated the token for
+	LastRotateAt string `json:"lastRotateAt,omitempty"`
+
+	// NextRotationAt is when the controller plans to rotate the token next, as an RFC 3339 timestamp
+	NextRotationAt string `json:"nextRotationAt,omitempty"`
+
+	// PlannedActions are what the controller would do for the Token if it wasn't in dry-run mode
+	PlannedActions []string `json:"plannedActions,omitempty"`
+
+	// Instances is the observed state of the token of each of the Token's Argo CD instances
+	Instances []InstanceStatus `json:"instances,omitempty"`
+
+	// Effective are the settings the controller used for the Token, after merging in its defaults
+	Effective *TokenSettings `json:"effective,omitempty"`
+}
+
+// InstanceStatus is the observed state of the token issued by one of a Token's Argo CD instances. Its fields mean
+// the same as in TokenStatus.
+type InstanceStatus struct {
+	Name string `json:"name"`
+
+	// ArgoCDEndpt and Key are where the instance's token was issued and stored, to revoke it and remove the key once the
+	// instance is no longer configured
+	ArgoCDEndpt string `json:"argocdendpt,omitempty"`
+
+	Key string `json:"key,omitempty"`
+
+	Conditions []TokenCondition `json:"conditions,omitempty"`
+
+	LastRotateAt string `json:"lastRotateAt,omitempty"`
+
+	NextRotationAt string `json:"nextRotationAt,omitempty"`
+
+	PlannedActions []string `json:"plannedActions,omitempty"`
+}
+
+// TokenConditionType is the type of a TokenCondition
+type TokenConditionType string
+
+const (
+	// TokenConditionArgoCDUnavailable is true while calls to Argo CD are paused because it keeps failing
+	TokenConditionArgoCDUnavailable TokenConditionType = "ArgoCDUnavailable"
+	// TokenConditionSuspended is true while the Token is suspended through spec.suspend
+	TokenConditionSuspended TokenConditionType = "Suspended"
+)
+
+// TokenCondition describes one aspect of the observed state of a Token
+type TokenCondition struct {
+	Type TokenConditionType `json:"type"`
+
+	Status corev1.ConditionStatus `json:"status"`
+
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+
+	Reason string `json:"reason,omitempty"`
+
+	Message string `json:"message,omitempty"`
+}
+
+// GetCondition returns the condition of the given type, or nil if it is not set
+func (s *TokenStatus) GetCondition(conditionType TokenConditionType) *TokenCondition {
+	for i := range s.Conditions {
+		if s.Conditions[i].Type == conditionType {
+			return &s.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// SetCondition adds or replaces the condition of the same type. LastTransitionTime only moves when the
+// status changes. It returns false if the condition was already set as given.
+func (s *TokenStatus) SetCondition(condition TokenCondition) bool {
+	existing := s.GetCondition(condition.Type)
+	if existing == nil {
+		condition.LastTransitionTime = metav1.Now()
+		s.Conditions = append(s.Conditions, condition)
+		return true
+	}
+
+	if existing.Status == condition.Status && existing.Reason == condition.Reason && existing.Message == condition.Message {
+		return false
+	}
+
+	if existing.Status == condition.Status {
+		condition.LastTransitionTime = existing.LastTransitionTime
+	} else {
+		condition.LastTransitionTime = metav1.Now()
+	}
+	*existing = condition
+	return true
 }
 
 // SecretReference defines desired information of Secret objects
@@ -54,6 +252,7 @@ type SecretReference struct {
 }
 
 // +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
 
 // Token is the Schema for the tokens API
 type Token struct {
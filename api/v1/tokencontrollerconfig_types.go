@@ -0,0 +1,107 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TokenControllerConfig is the configuration file of the controller, loaded with --config. It is not served by the
+// API server.
+type TokenControllerConfig struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// TokenDefaults are merged under the spec of every Token
+	TokenDefaults TokenSettings `json:"tokenDefaults,omitempty"`
+
+	// ArgoCD configures the controller's connections to Argo CD. Flags given on the command line take precedence.
+	ArgoCD ArgoCDConnection `json:"argocd,omitempty"`
+}
+
+// TokenSettings are the settings of a Token that can be defaulted for all Tokens
+type TokenSettings struct {
+	// ArgoCDEndpt is the Argo CD endpoint of Tokens without one or instances
+	ArgoCDEndpt string `json:"argocdendpt,omitempty"`
+
+	// Protocol is how the controller talks to Argo CD, see TokenSpec.Protocol
+	Protocol string `json:"protocol,omitempty"`
+
+	// ExpiresIn is how many seconds issued tokens are valid
+	ExpiresIn int `json:"expiresin,omitempty"`
+
+	// RenewPercent is how much of their lifetime tokens use up before they are rotated, e.g. 80 rotates a token valid
+	// for 10 hours after 8. 0 rotates tokens when they expire.
+	RenewPercent int `json:"renewPercent,omitempty"`
+
+	// SecretKey is the key of the Secret the token is stored in
+	SecretKey string `json:"secretKey,omitempty"`
+
+	// RotationSchedule restricts rotation to the windows it opens, see TokenSpec.RotationSchedule
+	RotationSchedule *RotationSchedule `json:"rotationSchedule,omitempty"`
+
+	// SecretLabels are added to the Secrets the controller creates
+	SecretLabels map[string]string `json:"secretLabels,omitempty"`
+}
+
+// ArgoCDConnection configures how the controller connects to Argo CD
+type ArgoCDConnection struct {
+	// CAFile is a CA bundle Argo CD's certificate is verified against, see --argocd-ca-file
+	CAFile string `json:"caFile,omitempty"`
+
+	// QPS is how many calls per second are made to one Argo CD endpoint, see --argocd-qps
+	QPS *float64 `json:"qps,omitempty"`
+
+	// Burst is how many calls to one endpoint may be made at once, see --argocd-burst
+	Burst *int `json:"burst,omitempty"`
+}
+
+// Apply fills the fields the spec leaves unset with the settings. A Token setting expiresin to 0 keeps tokens that
+// never expire despite a default expiry.
+func (s TokenSettings) Apply(spec *TokenSpec) {
+	if spec.ArgoCDEndpt == "" && len(spec.Instances) == 0 {
+		spec.ArgoCDEndpt = s.ArgoCDEndpt
+	}
+	if spec.Protocol == "" {
+		spec.Protocol = s.Protocol
+	}
+	if spec.ExpiresIn == nil && s.ExpiresIn != 0 {
+		expiresIn := s.ExpiresIn
+		spec.ExpiresIn = &expiresIn
+	}
+	if spec.SecretRef.Key == "" {
+		spec.SecretRef.Key = s.SecretKey
+	}
+	if spec.RotationSchedule == nil && s.RotationSchedule != nil {
+		spec.RotationSchedule = s.RotationSchedule.DeepCopy()
+	}
+}
+
+// Effective returns the settings a Token with the spec ends up with, after the settings were applied to it
+func (s TokenSettings) Effective(spec TokenSpec) TokenSettings {
+	s.Apply(&spec)
+	effective := TokenSettings{
+		ArgoCDEndpt:      spec.ArgoCDEndpt,
+		Protocol:         spec.Protocol,
+		ExpiresIn:        int(spec.ExpiresInSeconds()),
+		SecretKey:        spec.SecretRef.Key,
+		RotationSchedule: spec.RotationSchedule,
+		RenewPercent:     s.RenewPercent,
+	}
+	if len(s.SecretLabels) > 0 {
+		effective.SecretLabels = s.SecretLabels
+	}
+	return effective
+}
@@ -0,0 +1,77 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TokenSettings", func() {
+	defaults := TokenSettings{
+		ArgoCDEndpt:      "https://argocd.example.com",
+		Protocol:         ProtocolGRPC,
+		ExpiresIn:        3600,
+		SecretKey:        "token",
+		RotationSchedule: &RotationSchedule{Cron: "0 2 * * *"},
+		RenewPercent:     80,
+	}
+
+	It("fills the fields a spec leaves unset", func() {
+		spec := TokenSpec{SecretRef: SecretReference{Name: "ci-token"}}
+		defaults.Apply(&spec)
+		expiresIn := 3600
+		Expect(spec).To(Equal(TokenSpec{
+			ArgoCDEndpt:      "https://argocd.example.com",
+			Protocol:         ProtocolGRPC,
+			ExpiresIn:        &expiresIn,
+			SecretRef:        SecretReference{Name: "ci-token", Key: "token"},
+			RotationSchedule: &RotationSchedule{Cron: "0 2 * * *"},
+		}))
+
+		spec.RotationSchedule.Cron = "0 3 * * *"
+		Expect(defaults.RotationSchedule.Cron).To(Equal("0 2 * * *"))
+	})
+
+	It("keeps the fields a spec sets", func() {
+		expiresIn := 60
+		spec := TokenSpec{
+			ArgoCDEndpt: "https://other.example.com",
+			ExpiresIn:   &expiresIn,
+			SecretRef:   SecretReference{Name: "ci-token", Key: "jwt"},
+		}
+		effective := defaults.Effective(spec)
+		Expect(effective.ArgoCDEndpt).To(Equal("https://other.example.com"))
+		Expect(effective.ExpiresIn).To(Equal(60))
+		Expect(effective.SecretKey).To(Equal("jwt"))
+		Expect(effective.Protocol).To(Equal(ProtocolGRPC))
+		Expect(effective.RenewPercent).To(Equal(80))
+	})
+
+	It("keeps tokens that never expire when a spec asks for them", func() {
+		never := 0
+		spec := TokenSpec{ExpiresIn: &never}
+		defaults.Apply(&spec)
+		Expect(spec.ExpiresInSeconds()).To(BeZero())
+		Expect(defaults.Effective(spec).ExpiresIn).To(BeZero())
+	})
+
+	It("leaves the endpoint of Tokens with several instances unset", func() {
+		spec := TokenSpec{Instances: []ArgoCDInstance{{Name: "eu", ArgoCDEndpt: "https://eu.example.com"}}}
+		defaults.Apply(&spec)
+		Expect(spec.ArgoCDEndpt).To(BeEmpty())
+	})
+})
@@ -0,0 +1,248 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TokenSpec defines the desired state of Token
+type TokenSpec struct {
+	// ArgoCD is the Argo CD instance, or instances, the token is issued by
+	ArgoCD ArgoCDSpec `json:"argocd,omitempty"`
+
+	// Project is the Argo CD project of the role
+	Project string `json:"project"`
+
+	// Role is the project role the token is issued for
+	Role string `json:"role"`
+
+	// Expiry is how long issued tokens are valid
+	Expiry ExpirySpec `json:"expiry,omitempty"`
+
+	// Rotation controls when expiring tokens are replaced
+	Rotation RotationSpec `json:"rotation,omitempty"`
+
+	// Output is where the token is stored and who is told about a new one
+	Output OutputSpec `json:"output"`
+
+	// Suspend stops all calls to Argo CD and changes to the Secret for the Token until it is unset
+	Suspend bool `json:"suspend,omitempty"`
+
+	// DryRun makes the controller only record what it would do for the Token in its status and Events, without
+	// issuing or revoking tokens or writing the Secret
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+// ArgoCDSpec selects the Argo CD instance a token is issued by and how the controller talks to it
+type ArgoCDSpec struct {
+	// Server is the URL of Argo CD
+	Server string `json:"server,omitempty"`
+
+	// Protocol selects how the controller talks to Argo CD: rest (default), grpc or grpc-web
+	// +kubebuilder:validation:Enum=rest;grpc;grpc-web
+	Protocol string `json:"protocol,omitempty"`
+
+	// CredentialsRef selects a key of a Secret in the Token's namespace holding the Argo CD token the controller
+	// authenticates with, instead of its own credential
+	CredentialsRef *corev1.SecretKeySelector `json:"credentialsRef,omitempty"`
+
+	// Instances are several Argo CD instances with identically named projects to issue a token from each, instead
+	// of Server. Their tokens are stored in distinct keys of the Secret and rotated independently.
+	Instances []ArgoCDInstance `json:"instances,omitempty"`
+}
+
+// ArgoCDInstance is one of several Argo CD instances a Token issues tokens from
+type ArgoCDInstance struct {
+	// Name identifies the instance in the Token's status
+	// +kubebuilder:validation:Pattern=^[a-z0-9]([-a-z0-9]*[a-z0-9])?$
+	Name string `json:"name"`
+
+	// Server is the URL of the instance
+	Server string `json:"server"`
+
+	// Key is the key of the Secret holding the instance's token, it defaults to the output key and the name
+	// joined by a dash
+	Key string `json:"key,omitempty"`
+}
+
+// ExpirySpec is how long issued tokens are valid
+type ExpirySpec struct {
+	// Duration is the lifetime of issued tokens in whole seconds, e.g. 24h. Left unset it takes the controller's
+	// default, 0s asks for tokens that never expire.
+	Duration *metav1.Duration `json:"duration,omitempty"`
+}
+
+// RotationSpec controls when expiring tokens are replaced
+type RotationSpec struct {
+	// Schedule holds back rotation of an expiring token until a rotation window opens
+	Schedule *RotationSchedule `json:"schedule,omitempty"`
+}
+
+// RotationSchedule restricts rotation to windows opened by a cron expression. A token is rotated in the last
+// window before it expires, or as soon as it expires if no window opens in time.
+type RotationSchedule struct {
+	// Cron is a standard five field cron expression for when rotation windows open
+	Cron string `json:"cron"`
+
+	// TimeZone is the IANA time zone Cron and Blackouts are evaluated in, it defaults to UTC
+	TimeZone string `json:"timeZone,omitempty"`
+
+	// Window is how many seconds a rotation window stays open, it defaults to an hour
+	// +kubebuilder:validation:Minimum=60
+	Window int `json:"window,omitempty"`
+
+	// Blackouts are recurring windows in which no token is rotated, even if a rotation window is open
+	Blackouts []BlackoutWindow `json:"blackouts,omitempty"`
+}
+
+// BlackoutWindow is a recurring period in which tokens aren't rotated
+type BlackoutWindow struct {
+	// Cron is a standard five field cron expression for when the blackout starts
+	Cron string `json:"cron"`
+
+	// Duration is how many seconds the blackout lasts
+	// +kubebuilder:validation:Minimum=1
+	Duration int `json:"duration"`
+}
+
+// OutputSpec is where the token is stored and who is told about a new one
+type OutputSpec struct {
+	// Secret is the Secret in the Token's namespace the token is stored in
+	Secret SecretReference `json:"secret"`
+
+	// RolloutTargets are restarted whenever the token in the Secret is rotated, for workloads that only
+	// read it on startup, e.g. into an environment variable
+	RolloutTargets []RolloutTarget `json:"rolloutTargets,omitempty"`
+}
+
+// SecretReference selects a key of a Secret
+type SecretReference struct {
+	Name string `json:"name"`
+
+	// Key defaults to the secretKey of the controller's token defaults
+	Key string `json:"key,omitempty"`
+}
+
+// RolloutTarget selects workloads in the Token's namespace, either by name or by label selector
+type RolloutTarget struct {
+	// +kubebuilder:validation:Enum=Deployment;StatefulSet;DaemonSet
+	Kind string `json:"kind"`
+
+	Name string `json:"name,omitempty"`
+
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+}
+
+// TokenStatus defines the observed state of Token
+type TokenStatus struct {
+	Conditions []TokenCondition `json:"conditions,omitempty"`
+
+	// LastRotateAt is the last rotate-at annotation value the controller rotated the token for
+	LastRotateAt string `json:"lastRotateAt,omitempty"`
+
+	// NextRotationAt is when the controller plans to rotate the token next, as an RFC 3339 timestamp
+	NextRotationAt string `json:"nextRotationAt,omitempty"`
+
+	// PlannedActions are what the controller would do for the Token if it wasn't in dry-run mode
+	PlannedActions []string `json:"plannedActions,omitempty"`
+
+	// Instances is the observed state of the token of each of the Token's Argo CD instances
+	Instances []InstanceStatus `json:"instances,omitempty"`
+
+	// Effective are the settings the controller used for the Token, after merging in its defaults
+	Effective *TokenSettings `json:"effective,omitempty"`
+}
+
+// TokenSettings are the settings of a Token that can be defaulted for all Tokens
+type TokenSettings struct {
+	Server string `json:"server,omitempty"`
+
+	Protocol string `json:"protocol,omitempty"`
+
+	Expiry *metav1.Duration `json:"expiry,omitempty"`
+
+	SecretKey string `json:"secretKey,omitempty"`
+
+	RotationSchedule *RotationSchedule `json:"rotationSchedule,omitempty"`
+
+	SecretLabels map[string]string `json:"secretLabels,omitempty"`
+
+	RenewPercent int `json:"renewPercent,omitempty"`
+}
+
+// InstanceStatus is the observed state of the token issued by one of a Token's Argo CD instances. Its fields mean
+// the same as in TokenStatus.
+type InstanceStatus struct {
+	Name string `json:"name"`
+
+	// Server and Key are where the instance's token was issued and stored, to revoke it and remove the key once the
+	// instance is no longer configured
+	Server string `json:"server,omitempty"`
+
+	Key string `json:"key,omitempty"`
+
+	Conditions []TokenCondition `json:"conditions,omitempty"`
+
+	LastRotateAt string `json:"lastRotateAt,omitempty"`
+
+	NextRotationAt string `json:"nextRotationAt,omitempty"`
+
+	PlannedActions []string `json:"plannedActions,omitempty"`
+}
+
+// TokenConditionType is the type of a TokenCondition
+type TokenConditionType string
+
+// TokenCondition describes one aspect of the observed state of a Token
+type TokenCondition struct {
+	Type TokenConditionType `json:"type"`
+
+	Status corev1.ConditionStatus `json:"status"`
+
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+
+	Reason string `json:"reason,omitempty"`
+
+	Message string `json:"message,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+
+// Token is the Schema for the tokens API
+type Token struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   TokenSpec   `json:"spec,omitempty"`
+	Status TokenStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// TokenList contains a list of Token
+type TokenList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Token `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Token{}, &TokenList{})
+}
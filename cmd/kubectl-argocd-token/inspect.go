@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/argoproj-labs/argo-cd-tokens/utils/jwt"
+)
+
+func newInspectCommand(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "inspect TOKEN",
+		Short: "Decode the token held in a Token's Secret",
+		Long: "Decode the token held in a Token's Secret and check that it was issued for the Token's project " +
+			"and role. The signature is not verified.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.complete(); err != nil {
+				return err
+			}
+			return o.inspect(context.Background(), args[0])
+		},
+	}
+}
+
+func (o *options) inspect(ctx context.Context, name string) error {
+	token, jwtTkn, err := o.getToken(ctx, name)
+	if err != nil {
+		return err
+	}
+	if jwtTkn == "" {
+		return fmt.Errorf("Secret %s/%s holds no token under %s", token.Namespace, token.Spec.SecretRef.Name, token.Spec.SecretRef.Key)
+	}
+
+	claims, err := jwt.ParseClaims(jwtTkn)
+	if err != nil {
+		return err
+	}
+
+	now := o.clock.Now()
+	w := tabwriter.NewWriter(o.out, 0, 8, 2, ' ', 0)
+
+	fmt.Fprintf(w, "Token:\t%s/%s\n", token.Namespace, token.Name)
+	fmt.Fprintf(w, "Secret:\t%s/%s[%s]\n", token.Namespace, token.Spec.SecretRef.Name, token.Spec.SecretRef.Key)
+	fmt.Fprintf(w, "Issuer:\t%s\n", claims.Issuer)
+	fmt.Fprintf(w, "Subject:\t%s\n", claims.Subject)
+	if claims.ID != "" {
+		fmt.Fprintf(w, "ID:\t%s\n", claims.ID)
+	}
+	fmt.Fprintf(w, "Issued at:\t%s\n", formatUnix(claims.IssuedAt))
+	if claims.NotBefore != 0 {
+		fmt.Fprintf(w, "Not before:\t%s\n", formatUnix(claims.NotBefore))
+	}
+
+	if claims.Expires() {
+		fmt.Fprintf(w, "Expires at:\t%s\n", formatUnix(claims.ExpiresAt))
+		if claims.Expired(now) {
+			fmt.Fprintf(w, "Remaining:\texpired\n")
+		} else {
+			fmt.Fprintf(w, "Remaining:\t%s (%.0f%% of its lifetime used)\n",
+				claims.Remaining(now).Round(time.Second), claims.Elapsed(now)*100)
+		}
+	} else {
+		fmt.Fprintf(w, "Expires at:\tnever\n")
+	}
+
+	if err := jwt.VerifyToken(jwtTkn, token.Spec.Project, token.Spec.Role, nil); err != nil {
+		fmt.Fprintf(w, "Matches Token:\tno, %s\n", err)
+	} else {
+		fmt.Fprintf(w, "Matches Token:\tyes\n")
+	}
+
+	return w.Flush()
+}
+
+func formatUnix(seconds int64) string {
+	return time.Unix(seconds, 0).UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	argoprojlabsv1 "github.com/argoproj-labs/argo-cd-tokens/api/v1"
+	argoprojlabsv2 "github.com/argoproj-labs/argo-cd-tokens/api/v2"
+	"github.com/argoproj-labs/argo-cd-tokens/utils/jwt"
+)
+
+func newListCommand(o *options) *cobra.Command {
+	var allNamespaces bool
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List Tokens with their project, role, expiry and readiness",
+		Long: "List Tokens with their project, role, expiry and readiness. A Token is ready when its Secret " +
+			"holds an unexpired token and Argo CD is reachable.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.complete(); err != nil {
+				return err
+			}
+			return o.list(context.Background(), allNamespaces)
+		},
+	}
+	cmd.Flags().BoolVarP(&allNamespaces, "all-namespaces", "A", false, "List Tokens of all namespaces")
+
+	return cmd
+}
+
+func (o *options) list(ctx context.Context, allNamespaces bool) error {
+	var tokens argoprojlabsv2.TokenList
+
+	var listOpts []client.ListOptionFunc
+	if !allNamespaces {
+		listOpts = append(listOpts, client.InNamespace(o.namespace))
+	}
+	if err := o.client.List(ctx, &tokens, listOpts...); err != nil {
+		return err
+	}
+
+	if len(tokens.Items) == 0 {
+		fmt.Fprintln(o.out, "No Tokens found.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(o.out, 0, 8, 2, ' ', 0)
+	if allNamespaces {
+		fmt.Fprint(w, "NAMESPACE\t")
+	}
+	fmt.Fprintln(w, "NAME\tPROJECT\tROLE\tSECRET\tEXPIRES\tREADY")
+
+	for i := range tokens.Items {
+		var token argoprojlabsv1.Token
+		if err := token.ConvertFrom(&tokens.Items[i]); err != nil {
+			return err
+		}
+		token = effectiveToken(token)
+		expires, ready := o.describeExpiry(ctx, token)
+		if allNamespaces {
+			fmt.Fprintf(w, "%s\t", token.Namespace)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			token.Name, token.Spec.Project, token.Spec.Role, token.Spec.SecretRef.Name, expires, ready)
+	}
+
+	return w.Flush()
+}
+
+// describeExpiry tells when the token in the Token's Secret expires and whether the Token is ready
+func (o *options) describeExpiry(ctx context.Context, token argoprojlabsv1.Token) (string, string) {
+	jwtTkn, err := secretToken(ctx, o.client, token)
+	if err != nil {
+		return "<unknown>", "False"
+	}
+	if jwtTkn == "" {
+		return "<none>", "False"
+	}
+
+	claims, err := jwt.ParseClaims(jwtTkn)
+	if err != nil {
+		return "<invalid>", "False"
+	}
+
+	ready := "True"
+	if condition := token.Status.GetCondition(argoprojlabsv1.TokenConditionArgoCDUnavailable); condition != nil &&
+		condition.Status == corev1.ConditionTrue {
+		ready = "False"
+	}
+
+	now := o.clock.Now()
+	switch {
+	case !claims.Expires():
+		return "never", ready
+	case claims.Expired(now):
+		return "expired", "False"
+	default:
+		return "in " + claims.Remaining(now).Round(time.Second).String(), ready
+	}
+}
+
+// secretToken returns the token held in the Token's Secret, or "" if the Secret or key doesn't exist
+func secretToken(ctx context.Context, reader client.Reader, token argoprojlabsv1.Token) (string, error) {
+	var secret corev1.Secret
+	err := reader.Get(ctx, types.NamespacedName{Name: token.Spec.SecretRef.Name, Namespace: token.Namespace}, &secret)
+	if apierrors.IsNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(secret.Data[token.Spec.SecretRef.Key]), nil
+}
@@ -0,0 +1,133 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// kubectl-argocd-token lists and inspects Tokens and forces rotations and revocations.
+// Installed on the PATH it runs as "kubectl argocd-token".
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	argoprojlabsv1 "github.com/argoproj-labs/argo-cd-tokens/api/v1"
+	argoprojlabsv2 "github.com/argoproj-labs/argo-cd-tokens/api/v2"
+)
+
+func init() {
+	// Tokens are read in v2, the version they are stored in, so the plugin doesn't rely on the conversion webhook
+	argoprojlabsv2.AddToScheme(scheme.Scheme)
+}
+
+func main() {
+	if err := newRootCommand(&options{out: os.Stdout, clock: clock.RealClock{}}).Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// options are shared by all commands
+type options struct {
+	kubeconfig string
+	context    string
+	namespace  string
+
+	out   io.Writer
+	clock clock.Clock
+	// client is built from the kubeconfig on first use unless set up front, as tests do
+	client client.Client
+}
+
+func newRootCommand(o *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "kubectl-argocd-token",
+		Short:        "Inspect Argo CD Tokens and force token operations",
+		SilenceUsage: true,
+	}
+	cmd.PersistentFlags().StringVar(&o.kubeconfig, "kubeconfig", "", "Path to the kubeconfig file to use")
+	cmd.PersistentFlags().StringVar(&o.context, "context", "", "The kubeconfig context to use")
+	cmd.PersistentFlags().StringVarP(&o.namespace, "namespace", "n", "", "The namespace of the Tokens")
+	cmd.SetOutput(o.out)
+
+	cmd.AddCommand(newListCommand(o))
+	cmd.AddCommand(newInspectCommand(o))
+	cmd.AddCommand(newRotateCommand(o))
+	cmd.AddCommand(newRevokeCommand(o))
+
+	return cmd
+}
+
+// complete connects to the cluster and resolves the namespace from the kubeconfig when it wasn't given
+func (o *options) complete() error {
+	if o.client != nil {
+		if o.namespace == "" {
+			o.namespace = "default"
+		}
+		return nil
+	}
+
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = o.kubeconfig
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules,
+		&clientcmd.ConfigOverrides{CurrentContext: o.context})
+
+	if o.namespace == "" {
+		namespace, _, err := clientConfig.Namespace()
+		if err != nil {
+			return err
+		}
+		o.namespace = namespace
+	}
+
+	restConfig, err := clientConfig.ClientConfig()
+	if err != nil {
+		return err
+	}
+
+	o.client, err = client.New(restConfig, client.Options{Scheme: scheme.Scheme})
+	return err
+}
+
+// getToken fetches a Token of the namespace together with the token held in its Secret, which is "" if there is none
+func (o *options) getToken(ctx context.Context, name string) (argoprojlabsv1.Token, string, error) {
+	var token argoprojlabsv1.Token
+	var stored argoprojlabsv2.Token
+	err := o.client.Get(ctx, types.NamespacedName{Name: name, Namespace: o.namespace}, &stored)
+	if err != nil {
+		return token, "", err
+	}
+	if err := token.ConvertFrom(&stored); err != nil {
+		return token, "", err
+	}
+	token = effectiveToken(token)
+
+	jwtTkn, err := secretToken(ctx, o.client, token)
+	return token, jwtTkn, err
+}
+
+// effectiveToken returns the Token with the controller's defaults merged under its spec, as last recorded in its
+// status
+func effectiveToken(token argoprojlabsv1.Token) argoprojlabsv1.Token {
+	if token.Status.Effective != nil {
+		token.Status.Effective.Apply(&token.Spec)
+	}
+	return token
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/kubernetes/scheme"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	argoprojlabsv1 "github.com/argoproj-labs/argo-cd-tokens/api/v1"
+	argoprojlabsv2 "github.com/argoproj-labs/argo-cd-tokens/api/v2"
+	"github.com/argoproj-labs/argo-cd-tokens/utils/argocd/fake"
+	"github.com/argoproj-labs/argo-cd-tokens/utils/jwt"
+)
+
+func TestCommands(t *testing.T) {
+	ctx := context.Background()
+	argoCD := fake.NewArgoCD(fake.NewProject("default", "ci"))
+	server := fake.NewServer(argoCD, "admin-token")
+	defer server.Close()
+
+	expiresIn := 3600
+	token := argoprojlabsv1.Token{
+		ObjectMeta: metav1.ObjectMeta{Name: "ci-token", Namespace: "default"},
+		Spec: argoprojlabsv1.TokenSpec{
+			Project:     "default",
+			Role:        "ci",
+			ArgoCDEndpt: server.URL,
+			ExpiresIn:   &expiresIn,
+			SecretRef:   argoprojlabsv1.SecretReference{Name: "argocd-token", Key: "token"},
+		},
+	}
+	argoCDClient, err := argoCD.Client(ctx, token)
+	assert.Nil(t, err)
+	project, err := argoCDClient.GetProject(ctx)
+	assert.Nil(t, err)
+	jwtTkn, err := argoCDClient.GenerateToken(ctx, project)
+	assert.Nil(t, err)
+	claims, err := jwt.ParseClaims(jwtTkn)
+	assert.Nil(t, err)
+
+	secret := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "argocd-token", Namespace: "default"},
+		Data:       map[string][]byte{"token": []byte(jwtTkn)},
+	}
+	// the stored Token leaves its endpoint and key to the controller's defaults
+	stored := token.DeepCopy()
+	stored.Spec.ArgoCDEndpt = ""
+	stored.Spec.SecretRef.Key = ""
+	stored.Status.Effective = &argoprojlabsv1.TokenSettings{ArgoCDEndpt: server.URL, SecretKey: "token"}
+	missing := token.DeepCopy()
+	missing.Name = "missing-secret"
+	missing.Spec.SecretRef.Name = "missing"
+	// the API server serves Tokens in their v2 storage version
+	var storedHub, missingHub argoprojlabsv2.Token
+	assert.Nil(t, stored.ConvertTo(&storedHub))
+	assert.Nil(t, missing.ConvertTo(&missingHub))
+
+	var out bytes.Buffer
+	o := &options{
+		out:    &out,
+		clock:  clock.NewFakeClock(time.Unix(claims.IssuedAt, 0).Add(15 * time.Minute)),
+		client: fakeclient.NewFakeClientWithScheme(scheme.Scheme, &storedHub, &missingHub, &secret),
+	}
+	run := func(args ...string) error {
+		out.Reset()
+		cmd := newRootCommand(o)
+		cmd.SetArgs(args)
+		return cmd.Execute()
+	}
+
+	assert.Nil(t, run("list"))
+	assert.Contains(t, out.String(), "ci-token        default  ci    argocd-token  in 45m0s  True")
+	assert.Contains(t, out.String(), "missing-secret  default  ci    missing       <none>    False")
+
+	assert.Nil(t, run("inspect", "ci-token"))
+	assert.Contains(t, out.String(), "Subject:        proj:default:ci")
+	assert.Contains(t, out.String(), "Remaining:      45m0s (25% of its lifetime used)")
+	assert.Contains(t, out.String(), "Matches Token:  yes")
+	assert.NotNil(t, run("inspect", "missing-secret"))
+
+	assert.Nil(t, run("rotate", "ci-token"))
+	var rotated argoprojlabsv2.Token
+	assert.Nil(t, o.client.Get(ctx, types.NamespacedName{Name: "ci-token", Namespace: "default"}, &rotated))
+	assert.Equal(t, o.clock.Now().UTC().Format(time.RFC3339Nano), rotated.Annotations[argoprojlabsv1.RotateAtAnnotation])
+
+	// a second request within the same second asks for another rotation
+	first := rotated.Annotations[argoprojlabsv1.RotateAtAnnotation]
+	o.clock.(*clock.FakeClock).Step(100 * time.Millisecond)
+	assert.Nil(t, run("rotate", "ci-token"))
+	assert.Nil(t, o.client.Get(ctx, types.NamespacedName{Name: "ci-token", Namespace: "default"}, &rotated))
+	assert.NotEqual(t, first, rotated.Annotations[argoprojlabsv1.RotateAtAnnotation])
+
+	assert.NotNil(t, run("revoke", "ci-token", "--auth-token", "wrong"))
+	assert.Nil(t, run("revoke", "ci-token", "--auth-token", "admin-token"))
+	tokens, err := argoCDClient.ListTokens(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(tokens))
+}
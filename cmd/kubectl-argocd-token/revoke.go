@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/argoproj-labs/argo-cd-tokens/utils/argocd"
+	"github.com/argoproj-labs/argo-cd-tokens/utils/jwt"
+)
+
+func newRevokeCommand(o *options) *cobra.Command {
+	var authTkn string
+	var caFile string
+	var issuedAt int64
+	var rotate bool
+
+	cmd := &cobra.Command{
+		Use:   "revoke TOKEN",
+		Short: "Revoke a Token's token in Argo CD",
+		Long: "Revoke the token held in a Token's Secret, or the token of the Token's role issued at --issued-at, " +
+			"in Argo CD. Unless --rotate=false is given the controller is then asked to issue a new token.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if authTkn == "" {
+				return fmt.Errorf("an Argo CD token is needed to revoke tokens, set --auth-token or ARGOCD_AUTH_TOKEN")
+			}
+			if err := o.complete(); err != nil {
+				return err
+			}
+			return o.revoke(context.Background(), args[0], argocd.NewStaticAuth(authTkn), caFile, issuedAt, rotate)
+		},
+	}
+	cmd.Flags().StringVar(&authTkn, "auth-token", os.Getenv("ARGOCD_AUTH_TOKEN"),
+		"The Argo CD token to revoke with, defaults to ARGOCD_AUTH_TOKEN")
+	cmd.Flags().StringVar(&caFile, "argocd-ca-file", "",
+		"A PEM bundle of the CAs Argo CD's certificate is verified against. The certificate is not verified if unset.")
+	cmd.Flags().Int64Var(&issuedAt, "issued-at", 0,
+		"Revoke the token of the Token's role issued at this Unix time instead of the one in the Secret")
+	cmd.Flags().BoolVar(&rotate, "rotate", true, "Ask the controller to issue a new token after revoking")
+
+	return cmd
+}
+
+func (o *options) revoke(ctx context.Context, name string, auth argocd.AuthProvider, caFile string, issuedAt int64, rotate bool) error {
+	token, jwtTkn, err := o.getToken(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	if issuedAt == 0 {
+		if jwtTkn == "" {
+			return fmt.Errorf("Secret %s/%s holds no token to revoke", token.Namespace, token.Spec.SecretRef.Name)
+		}
+		claims, err := jwt.ParseClaims(jwtTkn)
+		if err != nil {
+			return err
+		}
+		issuedAt = claims.IssuedAt
+	}
+
+	argoCDClient, err := argocd.NewClientCache(auth, argocd.DefaultOptions(), nil, caFile, nil).Client(ctx, token)
+	if err != nil {
+		return err
+	}
+	err = argoCDClient.DeleteTokenIssuedAt(ctx, issuedAt)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(o.out, "Token of %s/%s issued at %s revoked\n", token.Namespace, token.Name, formatUnix(issuedAt))
+
+	if !rotate {
+		return nil
+	}
+	return o.rotate(ctx, name)
+}
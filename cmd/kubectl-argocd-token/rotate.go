@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	argoprojlabsv1 "github.com/argoproj-labs/argo-cd-tokens/api/v1"
+	argoprojlabsv2 "github.com/argoproj-labs/argo-cd-tokens/api/v2"
+)
+
+func newRotateCommand(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "rotate TOKEN",
+		Short: "Make the controller rotate a Token's token now",
+		Long: "Make the controller rotate a Token's token now by setting the " + argoprojlabsv1.RotateAtAnnotation +
+			" annotation. The new token is issued before the old one is revoked.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.complete(); err != nil {
+				return err
+			}
+			return o.rotate(context.Background(), args[0])
+		},
+	}
+}
+
+func (o *options) rotate(ctx context.Context, name string) error {
+	// nanoseconds keep two rotations requested within the same second apart
+	rotateAt := o.clock.Now().UTC().Format(time.RFC3339Nano)
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{argoprojlabsv1.RotateAtAnnotation: rotateAt},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	token := argoprojlabsv2.Token{}
+	token.Name = name
+	token.Namespace = o.namespace
+	err = o.client.Patch(ctx, &token, client.ConstantPatch(types.MergePatchType, patch))
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(o.out, "Token %s/%s will be rotated\n", o.namespace, name)
+	return nil
+}
@@ -0,0 +1,79 @@
+package controllers
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	argoprojlabsv1 "github.com/argoproj-labs/argo-cd-tokens/api/v1"
+	"github.com/argoproj-labs/argo-cd-tokens/utils/argocd"
+)
+
+// projectTokens maps an AppProject to the Tokens of this shard that issue their tokens from it
+func (r *TokenReconciler) projectTokens(a handler.MapObject) []reconcile.Request {
+	tokens, err := r.listTokens(context.Background())
+	if err != nil {
+		return []reconcile.Request{}
+	}
+
+	requests := make([]reconcile.Request, 0)
+	for _, token := range tokens {
+		if token.Spec.Project == a.Meta.GetName() && r.Shard.Owns(token.UID) && r.servedFromCluster(token) {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+				Name:      token.Name,
+				Namespace: token.Namespace,
+			}})
+		}
+	}
+	return requests
+}
+
+// servedFromCluster reports whether the Token, or one of its Argo CD instances, uses the in-cluster Argo CD
+func (r *TokenReconciler) servedFromCluster(token argoprojlabsv1.Token) bool {
+	token = r.withDefaults(token)
+	if len(token.Spec.Instances) == 0 {
+		return r.Projects.Serves(token.Spec.ArgoCDEndpt)
+	}
+	for _, instance := range token.Spec.Instances {
+		if r.Projects.Serves(instance.ArgoCDEndpt) {
+			return true
+		}
+	}
+	return false
+}
+
+// projectPredicate only lets through events of the in-cluster Argo CD's AppProjects that add or remove a role,
+// or change the tokens of one
+func (r *TokenReconciler) projectPredicate() predicate.Predicate {
+	inNamespace := func(namespace string) bool { return namespace == r.Projects.Namespace() }
+
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool { return inNamespace(e.Meta.GetNamespace()) },
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			if !inNamespace(e.MetaNew.GetNamespace()) {
+				return false
+			}
+			oldObject, oldOk := e.ObjectOld.(*unstructured.Unstructured)
+			newObject, newOk := e.ObjectNew.(*unstructured.Unstructured)
+			if !oldOk || !newOk {
+				return true
+			}
+			oldProject, err := argocd.ProjectFromObject(oldObject)
+			if err != nil {
+				return true
+			}
+			newProject, err := argocd.ProjectFromObject(newObject)
+			if err != nil {
+				return true
+			}
+			return argocd.RolesChanged(oldProject, newProject)
+		},
+		DeleteFunc:  func(e event.DeleteEvent) bool { return inNamespace(e.Meta.GetNamespace()) },
+		GenericFunc: func(e event.GenericEvent) bool { return inNamespace(e.Meta.GetNamespace()) },
+	}
+}
@@ -0,0 +1,131 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+
+	argoprojlabsv1 "github.com/argoproj-labs/argo-cd-tokens/api/v1"
+	"github.com/argoproj-labs/argo-cd-tokens/utils/argocd"
+)
+
+// testAppProject returns the in-cluster Argo CD's AppProject object with a ci role listing tokens issued at iats
+func testAppProject(name string, iats ...int64) *unstructured.Unstructured {
+	tokens := make([]interface{}, 0, len(iats))
+	for _, iat := range iats {
+		tokens = append(tokens, map[string]interface{}{"iat": iat})
+	}
+
+	object := argocd.NewAppProjectObject()
+	object.SetName(name)
+	object.SetNamespace("argocd")
+	object.Object["spec"] = map[string]interface{}{
+		"roles": []interface{}{map[string]interface{}{"name": "ci", "jwtTokens": tokens}},
+	}
+	return object
+}
+
+// newTestClusterProjects returns the projects of the in-cluster Argo CD at https://argocd.example.com
+func newTestClusterProjects(objects ...*unstructured.Unstructured) *argocd.ClusterProjects {
+	reader := fakeclient.NewFakeClientWithScheme(scheme.Scheme)
+	for _, object := range objects {
+		_ = reader.Create(context.Background(), object)
+	}
+	return argocd.NewClusterProjects(reader, "argocd", "https://argocd.example.com")
+}
+
+func TestProjectTokens(t *testing.T) {
+	inCluster := testToken(3600)
+	inCluster.Name = "in-cluster"
+	other := testToken(3600)
+	other.Name = "other-argocd"
+	other.Spec.ArgoCDEndpt = "https://other.example.com"
+	otherProject := testToken(3600)
+	otherProject.Name = "other-project"
+	otherProject.Spec.Project = "deploy"
+	instances := testToken(3600)
+	instances.Name = "instances"
+	instances.Spec.ArgoCDEndpt = ""
+	instances.Spec.Instances = []argoprojlabsv1.ArgoCDInstance{
+		{Name: "remote", ArgoCDEndpt: "https://other.example.com"},
+		{Name: "local", ArgoCDEndpt: "https://argocd.example.com"},
+	}
+
+	reconciler, _ := newTestReconciler(t, inCluster, other, otherProject, instances)
+	reconciler.Projects = newTestClusterProjects()
+
+	project := testAppProject("default")
+	requests := reconciler.projectTokens(handler.MapObject{Meta: project, Object: project})
+
+	// Tokens of other Argo CDs never find their tokens in the in-cluster projects
+	names := make([]string, 0, len(requests))
+	for _, request := range requests {
+		names = append(names, request.Name)
+	}
+	assert.ElementsMatch(t, []string{"in-cluster", "instances"}, names)
+}
+
+func TestProjectPredicate(t *testing.T) {
+	reconciler, _ := newTestReconciler(t)
+	reconciler.Projects = newTestClusterProjects()
+	projectPredicate := reconciler.projectPredicate()
+
+	project := testAppProject("default", 100)
+	assert.True(t, projectPredicate.Create(event.CreateEvent{Meta: project, Object: project}))
+	assert.True(t, projectPredicate.Delete(event.DeleteEvent{Meta: project, Object: project}))
+
+	elsewhere := testAppProject("default", 100)
+	elsewhere.SetNamespace("team-a")
+	assert.False(t, projectPredicate.Create(event.CreateEvent{Meta: elsewhere, Object: elsewhere}))
+
+	// only changes to the roles or their tokens reconcile the project's Tokens
+	described := testAppProject("default", 100)
+	described.Object["spec"].(map[string]interface{})["description"] = "changed"
+	assert.False(t, projectPredicate.Update(event.UpdateEvent{MetaOld: project, ObjectOld: project, MetaNew: described, ObjectNew: described}))
+	rotated := testAppProject("default", 200)
+	assert.True(t, projectPredicate.Update(event.UpdateEvent{MetaOld: project, ObjectOld: project, MetaNew: rotated, ObjectNew: rotated}))
+}
+
+func TestClusterProjectRevocationConfirmed(t *testing.T) {
+	ctx := context.Background()
+	token := testToken(3600)
+	reconciler, argoCD := newTestReconciler(t, token)
+	jwtTkn, iat := issueTestToken(t, argoCD, *token)
+	assert.Nil(t, reconciler.Create(ctx, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "argocd-token", Namespace: token.Namespace},
+		Data:       map[string][]byte{"token": []byte(jwtTkn)},
+	}))
+	clients := &revokingClients{ClientFactory: argoCD}
+	reconciler.Clients = clients
+	// the cache hasn't seen the token yet
+	reconciler.Projects = newTestClusterProjects(testAppProject("default"))
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: token.Name, Namespace: token.Namespace}}
+
+	_, err := reconciler.Reconcile(request)
+	assert.Nil(t, err)
+	assert.Equal(t, jwtTkn, storedToken(t, reconciler, token))
+	assert.Empty(t, clients.revoked)
+
+	// once Argo CD confirms it was revoked the token is replaced, and its iat deleted again
+	argoCDClient, err := argoCD.Client(ctx, *token)
+	assert.Nil(t, err)
+	assert.Nil(t, argoCDClient.DeleteTokenIssuedAt(ctx, iat))
+
+	_, err = reconciler.Reconcile(request)
+	assert.Nil(t, err)
+	// the fake Argo CD may sign the same token again within the second, so the patch is what shows the replacement
+	var secret corev1.Secret
+	assert.Nil(t, reconciler.Get(ctx, types.NamespacedName{Name: "argocd-token", Namespace: token.Namespace}, &secret))
+	assert.NotEmpty(t, secret.StringData["token"])
+	assert.Equal(t, []revocation{{endpoint: token.Spec.ArgoCDEndpt, iat: iat}}, clients.revoked)
+}
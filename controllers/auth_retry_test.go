@@ -0,0 +1,39 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	argoprojlabsv1 "github.com/argoproj-labs/argo-cd-tokens/api/v1"
+	"github.com/argoproj-labs/argo-cd-tokens/utils/argocd"
+)
+
+func TestAuthFailuresRetried(t *testing.T) {
+	reconciler := &TokenReconciler{
+		Log:          newTestLogger(),
+		authFailures: make(map[types.NamespacedName]struct{}),
+		retryAuth:    make(chan event.GenericEvent, 1),
+	}
+	token := argoprojlabsv1.Token{ObjectMeta: metav1.ObjectMeta{Name: "ci", Namespace: "default"}}
+
+	// a rejected Token is requeued with backoff
+	result, err := reconciler.argoCDFailed(context.Background(), &token, reconciler.Log, argocd.ErrUnauthorized)
+	assert.Nil(t, err)
+	assert.True(t, result.Requeue)
+
+	// and retried once the credential changed, staying listed until it was reconciled
+	reconciler.RetryAuthFailures()
+	select {
+	case evt := <-reconciler.retryAuth:
+		assert.Equal(t, "ci", evt.Meta.GetName())
+	case <-time.After(time.Second):
+		t.Fatal("the Token was not enqueued")
+	}
+	assert.Equal(t, 1, len(reconciler.authFailures))
+}
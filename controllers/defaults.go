@@ -0,0 +1,28 @@
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/equality"
+
+	argoprojlabsv1 "github.com/argoproj-labs/argo-cd-tokens/api/v1"
+)
+
+// withDefaults returns the Token with the controller's defaults merged under its spec
+func (r *TokenReconciler) withDefaults(token argoprojlabsv1.Token) argoprojlabsv1.Token {
+	r.Defaults.Apply(&token.Spec)
+	return token
+}
+
+// recordEffective shows the settings the Token is reconciled with in its status, skipping the write if they didn't
+// change. The Token's spec must have the defaults merged in already.
+func (r *TokenReconciler) recordEffective(ctx context.Context, token *argoprojlabsv1.Token, logCtx logr.Logger) {
+	effective := r.Defaults.Effective(token.Spec)
+	if token.Status.Effective != nil && equality.Semantic.DeepEqual(*token.Status.Effective, effective) {
+		return
+	}
+
+	token.Status.Effective = &effective
+	r.updateStatus(ctx, token, logCtx)
+}
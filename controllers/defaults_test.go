@@ -0,0 +1,49 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	argoprojlabsv1 "github.com/argoproj-labs/argo-cd-tokens/api/v1"
+)
+
+func TestTokenDefaults(t *testing.T) {
+	ctx := context.Background()
+	token := testToken(0)
+	token.Spec.ArgoCDEndpt = ""
+	token.Spec.SecretRef.Key = ""
+	token.Spec.ExpiresIn = nil
+	reconciler, _ := newTestReconciler(t, token)
+	reconciler.Defaults = argoprojlabsv1.TokenSettings{
+		ArgoCDEndpt:  "https://argocd.example.com",
+		ExpiresIn:    3600,
+		SecretKey:    "token",
+		SecretLabels: map[string]string{"team": "ci"},
+	}
+
+	// the controller's defaults are merged under the Token's spec and the result is shown in its status
+	defaulted := reconciler.withDefaults(*token)
+	reconciler.recordEffective(ctx, &defaulted, reconciler.Log)
+	assert.Equal(t, "https://argocd.example.com", defaulted.Spec.ArgoCDEndpt)
+	assert.Equal(t, "token", defaulted.Spec.SecretRef.Key)
+
+	stored := getTestToken(t, reconciler, token)
+	assert.Equal(t, &argoprojlabsv1.TokenSettings{
+		ArgoCDEndpt:  "https://argocd.example.com",
+		ExpiresIn:    3600,
+		SecretKey:    "token",
+		SecretLabels: map[string]string{"team": "ci"},
+	}, stored.Status.Effective)
+
+	// a Token asking for tokens that never expire keeps them despite the default expiry
+	never := testToken(0)
+	assert.Zero(t, reconciler.withDefaults(*never).Spec.ExpiresInSeconds())
+	assert.Zero(t, reconciler.Defaults.Effective(never.Spec).ExpiresIn)
+
+	secret, err := reconciler.createSecret(ctx, "tkn", reconciler.Log, defaulted)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"team": "ci"}, secret.Labels)
+	assert.Equal(t, "tkn", secret.StringData["token"])
+}
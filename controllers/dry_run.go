@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	argoprojlabsv1 "github.com/argoproj-labs/argo-cd-tokens/api/v1"
+	"github.com/argoproj-labs/argo-cd-tokens/utils/argocd"
+	"github.com/argoproj-labs/argo-cd-tokens/utils/jwt"
+)
+
+// isDryRun reports whether the controller only plans what it would do for the Token
+func (r *TokenReconciler) isDryRun(token argoprojlabsv1.Token) bool {
+	return r.DryRun || token.Spec.DryRun
+}
+
+// recordPlan records the actions a dry run would take in the Token's status, and as Events whenever they change
+func (r *TokenReconciler) recordPlan(ctx context.Context, token *argoprojlabsv1.Token, logCtx logr.Logger, actions []string) {
+	if equalActions(token.Status.PlannedActions, actions) {
+		return
+	}
+
+	token.Status.PlannedActions = actions
+	r.updateStatus(ctx, token, logCtx)
+
+	// Events refer to the Token in the version it is stored in
+	stored, err := hubToken(token)
+	if err != nil {
+		logCtx.Info(err.Error())
+		return
+	}
+	for _, action := range actions {
+		logCtx.Info("Dry run: " + action)
+		r.Recorder.Event(stored, corev1.EventTypeNormal, "DryRun", action)
+	}
+}
+
+// planIssue records the actions of issuing a new token and storing it in the Token's Secret. revoke is logged
+// before or after, following the order a real reconcile takes.
+func (r *TokenReconciler) planIssue(ctx context.Context, token *argoprojlabsv1.Token, logCtx logr.Logger, project argocd.AppProject,
+	secretAction string, revokeFirst string, revokeAfter string) ctrl.Result {
+
+	var actions []string
+	if revokeFirst != "" {
+		actions = append(actions, revokeFirst)
+	}
+	if argocd.RoleExists(token.Spec.Role, project) {
+		actions = append(actions, fmt.Sprintf("Issue a token for role %s of project %s", token.Spec.Role, token.Spec.Project))
+		actions = append(actions, secretAction)
+		actions = append(actions, r.planRollout(ctx, *token)...)
+	} else {
+		actions = append(actions, fmt.Sprintf("Fail to issue a token, role %s does not exist in project %s", token.Spec.Role, token.Spec.Project))
+	}
+	if revokeAfter != "" {
+		actions = append(actions, revokeAfter)
+	}
+
+	r.recordPlan(ctx, token, logCtx, actions)
+	return ctrl.Result{}
+}
+
+// planRollout lists the workloads that would be restarted once the Token's Secret was updated
+func (r *TokenReconciler) planRollout(ctx context.Context, token argoprojlabsv1.Token) []string {
+	var actions []string
+	for _, target := range token.Spec.RolloutTargets {
+		workloads, err := r.rolloutWorkloads(ctx, token.Namespace, target)
+		if err != nil {
+			actions = append(actions, fmt.Sprintf("Fail to restart %s targets: %v", target.Kind, err))
+			continue
+		}
+		for _, workload := range workloads {
+			actions = append(actions, fmt.Sprintf("Restart %s %s", target.Kind, workloadName(workload)))
+		}
+	}
+	return actions
+}
+
+// revokeAction describes revoking the token the claims belong to
+func revokeAction(claims *jwt.Claims, why string) string {
+	return fmt.Sprintf("Revoke the %stoken issued at %s", why, time.Unix(claims.IssuedAt, 0).UTC().Format(time.RFC3339))
+}
+
+func equalActions(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
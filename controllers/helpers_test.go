@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	gojwt "github.com/dgrijalva/jwt-go"
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	argoprojlabsv1 "github.com/argoproj-labs/argo-cd-tokens/api/v1"
+	argoprojlabsv2 "github.com/argoproj-labs/argo-cd-tokens/api/v2"
+	"github.com/argoproj-labs/argo-cd-tokens/utils/argocd"
+	"github.com/argoproj-labs/argo-cd-tokens/utils/argocd/fake"
+	"github.com/argoproj-labs/argo-cd-tokens/utils/jwt"
+)
+
+// newTestReconciler returns a reconciler that reads the objects from a fake API server and talks to a fake Argo CD
+// holding the default project with a ci role. v1 Tokens are stored in v2, like the API server does.
+func newTestReconciler(t *testing.T, objects ...runtime.Object) (*TokenReconciler, *fake.ArgoCD) {
+	scheme := runtime.NewScheme()
+	assert.Nil(t, corev1.AddToScheme(scheme))
+	assert.Nil(t, argoprojlabsv1.AddToScheme(scheme))
+	assert.Nil(t, argoprojlabsv2.AddToScheme(scheme))
+	for i, object := range objects {
+		if token, ok := object.(*argoprojlabsv1.Token); ok {
+			objects[i] = testHubToken(t, token)
+		}
+	}
+
+	argoCD := fake.NewArgoCD(fake.NewProject("default", "ci"))
+	return &TokenReconciler{
+		Client:       fakeclient.NewFakeClientWithScheme(scheme, objects...),
+		Log:          newTestLogger(),
+		Auth:         argocd.NewStaticAuth("admin-token"),
+		Breakers:     argocd.NewCircuitBreakers(5, 30*time.Second),
+		Clients:      argoCD,
+		Clock:        clock.RealClock{},
+		authFailures: make(map[types.NamespacedName]struct{}),
+		retryAuth:    make(chan event.GenericEvent, 1),
+	}, argoCD
+}
+
+// testHubToken returns the Token in the version it is stored in
+func testHubToken(t *testing.T, token *argoprojlabsv1.Token) *argoprojlabsv2.Token {
+	stored, err := hubToken(token)
+	assert.Nil(t, err)
+	return stored
+}
+
+// getTestToken returns the Token as stored by the reconciler
+func getTestToken(t *testing.T, reconciler *TokenReconciler, token *argoprojlabsv1.Token) argoprojlabsv1.Token {
+	var stored argoprojlabsv1.Token
+	assert.Nil(t, reconciler.getToken(context.Background(), types.NamespacedName{Name: token.Name, Namespace: token.Namespace}, &stored))
+	return stored
+}
+
+// newTestLogger returns the logger reconciler tests log to
+func newTestLogger() logr.Logger {
+	return zap.Logger(true)
+}
+
+// signedTestToken returns a token of the ci role issued at iat that expires after lifetime
+func signedTestToken(t *testing.T, iat time.Time, lifetime time.Duration) string {
+	jwtTkn, err := gojwt.NewWithClaims(gojwt.SigningMethodHS256, gojwt.MapClaims{
+		"iat": iat.Unix(),
+		"exp": iat.Add(lifetime).Unix(),
+		"iss": jwt.Issuer,
+		"sub": "proj:default:ci",
+	}).SignedString([]byte("test"))
+	assert.Nil(t, err)
+	return jwtTkn
+}
+
+// testToken returns a Token of the ci role of the default project, storing its token in the argocd-token Secret
+func testToken(expiresIn int) *argoprojlabsv1.Token {
+	return &argoprojlabsv1.Token{
+		ObjectMeta: metav1.ObjectMeta{Name: "ci-token", Namespace: "default"},
+		Spec: argoprojlabsv1.TokenSpec{
+			ArgoCDEndpt: "https://argocd.example.com",
+			Project:     "default",
+			Role:        "ci",
+			ExpiresIn:   &expiresIn,
+			SecretRef:   argoprojlabsv1.SecretReference{Name: "argocd-token", Key: "token"},
+		},
+	}
+}
+
+// storedToken returns the token the Token's Secret holds, reading the value written by the last patch if there is one
+func storedToken(t *testing.T, c client.Client, token *argoprojlabsv1.Token) string {
+	var secret corev1.Secret
+	err := c.Get(context.Background(), types.NamespacedName{Name: token.Spec.SecretRef.Name, Namespace: token.Namespace}, &secret)
+	if err != nil {
+		return ""
+	}
+	// the fake client doesn't fold stringData into data like the API server does
+	if value, ok := secret.StringData[token.Spec.SecretRef.Key]; ok {
+		return value
+	}
+	return string(secret.Data[token.Spec.SecretRef.Key])
+}
@@ -0,0 +1,238 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	argoprojlabsv1 "github.com/argoproj-labs/argo-cd-tokens/api/v1"
+	"github.com/argoproj-labs/argo-cd-tokens/utils/argocd"
+	"github.com/argoproj-labs/argo-cd-tokens/utils/jwt"
+)
+
+// deferStatusKey marks the context of reconciling one of a Token's Argo CD instances. Its status is written
+// together with the Token's once every instance was reconciled.
+type deferStatusKey struct{}
+
+// updateStatus writes the Token's status, unless it is deferred by reconcileInstances
+func (r *TokenReconciler) updateStatus(ctx context.Context, token *argoprojlabsv1.Token, logCtx logr.Logger) {
+	if ctx.Value(deferStatusKey{}) != nil {
+		return
+	}
+
+	err := r.updateTokenStatus(ctx, token)
+	if err != nil {
+		logCtx.Info(err.Error())
+	}
+	// the update returns the Token as stored, without the controller's defaults
+	r.Defaults.Apply(&token.Spec)
+}
+
+// reconcileInstances reconciles the token of each of the Token's Argo CD instances on its own, as if every instance
+// had a Token of its own, and requeues the Token for the earliest rotation of any of them. stale are the keys of
+// instances that are no longer configured and still have to be pruned, they are kept in the status to be retried.
+func (r *TokenReconciler) reconcileInstances(ctx context.Context, token *argoprojlabsv1.Token, stale []argoprojlabsv1.InstanceStatus,
+	logCtx logr.Logger) (ctrl.Result, error) {
+
+	instanceCtx := context.WithValue(ctx, deferStatusKey{}, true)
+
+	var result ctrl.Result
+	statuses := make([]argoprojlabsv1.InstanceStatus, 0, len(token.Spec.Instances)+len(stale))
+	for _, instance := range token.Spec.Instances {
+		view := instanceToken(*token, instance)
+		instanceResult, _ := r.reconcileToken(instanceCtx, &view, logCtx.WithValues("instance", instance.Name))
+
+		statuses = append(statuses, argoprojlabsv1.InstanceStatus{
+			Name:           instance.Name,
+			ArgoCDEndpt:    view.Spec.ArgoCDEndpt,
+			Key:            view.Spec.SecretRef.Key,
+			Conditions:     view.Status.Conditions,
+			LastRotateAt:   view.Status.LastRotateAt,
+			NextRotationAt: view.Status.NextRotationAt,
+			PlannedActions: view.Status.PlannedActions,
+		})
+		result = earlierResult(result, instanceResult)
+	}
+	statuses = append(statuses, stale...)
+	result.Requeue = result.Requeue || r.pruneRetried(*token, stale)
+
+	if !equality.Semantic.DeepEqual(token.Status.Instances, statuses) {
+		token.Status.Instances = statuses
+		r.updateStatus(ctx, token, logCtx)
+	}
+	return result, nil
+}
+
+// instanceToken returns the Token as reconciled for one of its Argo CD instances
+func instanceToken(token argoprojlabsv1.Token, instance argoprojlabsv1.ArgoCDInstance) argoprojlabsv1.Token {
+	view := *token.DeepCopy()
+	view.Spec.ArgoCDEndpt = instance.ArgoCDEndpt
+	view.Spec.SecretRef.Key = instanceKey(token, instance)
+	view.Spec.Instances = nil
+
+	view.Status = argoprojlabsv1.TokenStatus{}
+	current := argoprojlabsv1.InstanceStatus{Name: instance.Name, ArgoCDEndpt: view.Spec.ArgoCDEndpt, Key: view.Spec.SecretRef.Key}
+	for _, status := range token.Status.Instances {
+		if sameKey(status, current) {
+			view.Status.Conditions = status.Conditions
+			view.Status.LastRotateAt = status.LastRotateAt
+			view.Status.NextRotationAt = status.NextRotationAt
+			view.Status.PlannedActions = status.PlannedActions
+		}
+	}
+	return view
+}
+
+// instanceKey returns the key of the Token's Secret holding the token of one of its Argo CD instances
+func instanceKey(token argoprojlabsv1.Token, instance argoprojlabsv1.ArgoCDInstance) string {
+	if instance.Key != "" {
+		return instance.Key
+	}
+	return token.Spec.SecretRef.Key + "-" + instance.Name
+}
+
+// currentKeys returns the keys the Token's spec stores tokens under and the Argo CD endpoints issuing them, with the
+// single endpoint of a Token without instances under an empty name
+func currentKeys(token argoprojlabsv1.Token) []argoprojlabsv1.InstanceStatus {
+	if len(token.Spec.Instances) == 0 {
+		return []argoprojlabsv1.InstanceStatus{{ArgoCDEndpt: token.Spec.ArgoCDEndpt, Key: token.Spec.SecretRef.Key}}
+	}
+	keys := make([]argoprojlabsv1.InstanceStatus, 0, len(token.Spec.Instances))
+	for _, instance := range token.Spec.Instances {
+		keys = append(keys, argoprojlabsv1.InstanceStatus{
+			Name:        instance.Name,
+			ArgoCDEndpt: instance.ArgoCDEndpt,
+			Key:         instanceKey(token, instance),
+		})
+	}
+	return keys
+}
+
+// staleKeys returns the keys the Token's tokens were stored under that its spec no longer maps to the same Argo CD
+// endpoint: those of removed or changed instances, and the key of its single endpoint once it switched to instances.
+// previous are the settings the Token was last reconciled with.
+func staleKeys(token argoprojlabsv1.Token, previous *argoprojlabsv1.TokenSettings) []argoprojlabsv1.InstanceStatus {
+	held := token.Status.Instances
+	if len(held) == 0 && len(token.Spec.Instances) > 0 && previous != nil && previous.ArgoCDEndpt != "" && previous.SecretKey != "" {
+		held = []argoprojlabsv1.InstanceStatus{{ArgoCDEndpt: previous.ArgoCDEndpt, Key: previous.SecretKey}}
+	}
+
+	current := currentKeys(token)
+	var stale []argoprojlabsv1.InstanceStatus
+	for _, status := range held {
+		if status.ArgoCDEndpt == "" || status.Key == "" {
+			continue
+		}
+		configured := false
+		for _, key := range current {
+			configured = configured || sameKey(status, key)
+		}
+		if !configured {
+			stale = append(stale, status)
+		}
+	}
+	return stale
+}
+
+// sameKey reports whether both statuses are of the same instance storing its token under the same key
+func sameKey(a argoprojlabsv1.InstanceStatus, b argoprojlabsv1.InstanceStatus) bool {
+	return a.Name == b.Name && a.ArgoCDEndpt == b.ArgoCDEndpt && a.Key == b.Key
+}
+
+// pruneKeys revokes the tokens stored under stale keys and removes the keys from the Token's Secret, leaving keys the
+// spec still stores a token under. It returns the keys that couldn't be pruned yet, or that are only planned to be
+// pruned in dry-run mode.
+func (r *TokenReconciler) pruneKeys(ctx context.Context, token argoprojlabsv1.Token, stale []argoprojlabsv1.InstanceStatus,
+	logCtx logr.Logger) []argoprojlabsv1.InstanceStatus {
+
+	var pending []argoprojlabsv1.InstanceStatus
+	for _, status := range stale {
+		status.PlannedActions = nil
+		if r.isDryRun(token) {
+			status.PlannedActions = []string{fmt.Sprintf("Revoke the token under key %s of Secret %s issued by %s and remove the key",
+				status.Key, token.Spec.SecretRef.Name, status.ArgoCDEndpt)}
+			pending = append(pending, status)
+			continue
+		}
+		if err := r.pruneKey(ctx, token, status); err != nil {
+			logCtx.Info(err.Error(), "key", status.Key)
+			pending = append(pending, status)
+			continue
+		}
+		logCtx.Info("Token of an instance that is no longer configured revoked!", "key", status.Key)
+	}
+	return pending
+}
+
+// pruneRetried reports whether the Token has to be requeued to prune the pending keys again
+func (r *TokenReconciler) pruneRetried(token argoprojlabsv1.Token, pending []argoprojlabsv1.InstanceStatus) bool {
+	return len(pending) > 0 && !r.isDryRun(token)
+}
+
+// pruneKey revokes the token stored under the stale key and removes the key from the Token's Secret
+func (r *TokenReconciler) pruneKey(ctx context.Context, token argoprojlabsv1.Token, stale argoprojlabsv1.InstanceStatus) error {
+	if openFor := r.Breakers.OpenFor(stale.ArgoCDEndpt); openFor > 0 {
+		return argocd.ErrArgoCDUnavailable
+	}
+
+	var tknSecret corev1.Secret
+	err := r.Get(ctx, types.NamespacedName{Name: token.Spec.SecretRef.Name, Namespace: token.Namespace}, &tknSecret)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	jwtTkn, ok := tknSecret.Data[stale.Key]
+	if !ok {
+		return nil
+	}
+
+	// a value that isn't a token was never issued by Argo CD, so there is nothing to revoke
+	if claims, err := jwt.ParseClaims(string(jwtTkn)); err == nil {
+		view := *token.DeepCopy()
+		view.Spec.ArgoCDEndpt = stale.ArgoCDEndpt
+		view.Spec.SecretRef.Key = stale.Key
+		view.Spec.Instances = nil
+
+		argoCtx, cancel := r.ArgoCDOptions.WithTimeout(ctx)
+		defer cancel()
+		argoCDClient, err := r.Clients.Client(argoCtx, view)
+		if err != nil {
+			return err
+		}
+		err = argoCDClient.DeleteTokenIssuedAt(argoCtx, claims.IssuedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, key := range currentKeys(token) {
+		if key.Key == stale.Key {
+			// the key holds the token of a configured instance from now on, which replaces the revoked token
+			return nil
+		}
+	}
+	delete(tknSecret.Data, stale.Key)
+	return r.Update(ctx, &tknSecret)
+}
+
+// earlierResult merges the results of reconciling two instances into the one requeueing earliest
+func earlierResult(a ctrl.Result, b ctrl.Result) ctrl.Result {
+	merged := ctrl.Result{Requeue: a.Requeue || b.Requeue}
+	switch {
+	case a.RequeueAfter == 0:
+		merged.RequeueAfter = b.RequeueAfter
+	case b.RequeueAfter == 0 || a.RequeueAfter < b.RequeueAfter:
+		merged.RequeueAfter = a.RequeueAfter
+	default:
+		merged.RequeueAfter = b.RequeueAfter
+	}
+	return merged
+}
@@ -0,0 +1,243 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	argoprojlabsv1 "github.com/argoproj-labs/argo-cd-tokens/api/v1"
+	"github.com/argoproj-labs/argo-cd-tokens/utils/argocd"
+	"github.com/argoproj-labs/argo-cd-tokens/utils/jwt"
+)
+
+// revocation is a token revoked through the Argo CD endpoint
+type revocation struct {
+	endpoint string
+	iat      int64
+}
+
+// revokingClients records the tokens revoked through the clients it hands out
+type revokingClients struct {
+	argocd.ClientFactory
+	revoked []revocation
+}
+
+func (r *revokingClients) Client(ctx context.Context, token argoprojlabsv1.Token) (argocd.ArgoCDAPI, error) {
+	argoCDClient, err := r.ClientFactory.Client(ctx, token)
+	return &revokingClient{ArgoCDAPI: argoCDClient, endpoint: token.Spec.ArgoCDEndpt, clients: r}, err
+}
+
+type revokingClient struct {
+	argocd.ArgoCDAPI
+	endpoint string
+	clients  *revokingClients
+}
+
+func (r *revokingClient) DeleteTokenIssuedAt(ctx context.Context, iat int64) error {
+	r.clients.revoked = append(r.clients.revoked, revocation{endpoint: r.endpoint, iat: iat})
+	return r.ArgoCDAPI.DeleteTokenIssuedAt(ctx, iat)
+}
+
+// issueTestToken mints a token of the Token's role in the fake Argo CD, returning it and its iat
+func issueTestToken(t *testing.T, clients argocd.ClientFactory, token argoprojlabsv1.Token) (string, int64) {
+	ctx := context.Background()
+	argoCDClient, err := clients.Client(ctx, token)
+	assert.Nil(t, err)
+	project, err := argoCDClient.GetProject(ctx)
+	assert.Nil(t, err)
+	jwtTkn, err := argoCDClient.GenerateToken(ctx, project)
+	assert.Nil(t, err)
+	claims, err := jwt.ParseClaims(jwtTkn)
+	assert.Nil(t, err)
+	return jwtTkn, claims.IssuedAt
+}
+
+func TestPruneRemovedInstances(t *testing.T) {
+	ctx := context.Background()
+	token := testToken(3600)
+	token.Spec.ArgoCDEndpt = ""
+	token.Spec.Instances = []argoprojlabsv1.ArgoCDInstance{
+		{Name: "eu", ArgoCDEndpt: "https://eu.argocd.example.com"},
+		{Name: "us", ArgoCDEndpt: "https://us.argocd.example.com"},
+	}
+	reconciler, argoCD := newTestReconciler(t)
+
+	euTkn, euIAT := issueTestToken(t, argoCD, instanceToken(*token, token.Spec.Instances[0]))
+	usTkn, usIAT := issueTestToken(t, argoCD, instanceToken(*token, token.Spec.Instances[1]))
+	token.Status.Instances = []argoprojlabsv1.InstanceStatus{
+		{Name: "eu", ArgoCDEndpt: "https://eu.argocd.example.com", Key: "token-eu"},
+		{Name: "us", ArgoCDEndpt: "https://us.argocd.example.com", Key: "token-us"},
+	}
+	// the us instance is removed
+	token.Spec.Instances = token.Spec.Instances[:1]
+
+	assert.Nil(t, reconciler.Create(ctx, testHubToken(t, token)))
+	assert.Nil(t, reconciler.Create(ctx, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "argocd-token", Namespace: token.Namespace},
+		Data:       map[string][]byte{"token-eu": []byte(euTkn), "token-us": []byte(usTkn)},
+	}))
+	clients := &revokingClients{ClientFactory: argoCD}
+	reconciler.Clients = clients
+
+	result, err := reconciler.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: token.Name, Namespace: token.Namespace}})
+	assert.Nil(t, err)
+	assert.False(t, result.Requeue)
+
+	assert.Equal(t, []revocation{{endpoint: "https://us.argocd.example.com", iat: usIAT}}, clients.revoked)
+	assert.NotContains(t, listedIssuedAts(t, argoCD), usIAT)
+	assert.Contains(t, listedIssuedAts(t, argoCD), euIAT)
+
+	var secret corev1.Secret
+	assert.Nil(t, reconciler.Get(ctx, types.NamespacedName{Name: "argocd-token", Namespace: token.Namespace}, &secret))
+	assert.NotContains(t, secret.Data, "token-us")
+	assert.Equal(t, euTkn, string(secret.Data["token-eu"]))
+
+	stored := getTestToken(t, reconciler, token)
+	assert.Equal(t, 1, len(stored.Status.Instances))
+	assert.Equal(t, "eu", stored.Status.Instances[0].Name)
+	assert.Equal(t, "token-eu", stored.Status.Instances[0].Key)
+}
+
+func TestPruneSingleEndpointOnSwitchToInstances(t *testing.T) {
+	ctx := context.Background()
+	token := testToken(3600)
+	reconciler, argoCD := newTestReconciler(t)
+	oldTkn, oldIAT := issueTestToken(t, argoCD, *token)
+	token.Status.Effective = &argoprojlabsv1.TokenSettings{ArgoCDEndpt: token.Spec.ArgoCDEndpt, ExpiresIn: 3600, SecretKey: "token"}
+
+	// the Token switches from its single endpoint to instances
+	token.Spec.ArgoCDEndpt = ""
+	token.Spec.Instances = []argoprojlabsv1.ArgoCDInstance{{Name: "eu", ArgoCDEndpt: "https://eu.argocd.example.com"}}
+
+	assert.Nil(t, reconciler.Create(ctx, testHubToken(t, token)))
+	assert.Nil(t, reconciler.Create(ctx, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "argocd-token", Namespace: token.Namespace},
+		Data:       map[string][]byte{"token": []byte(oldTkn)},
+	}))
+	clients := &revokingClients{ClientFactory: argoCD}
+	reconciler.Clients = clients
+
+	_, err := reconciler.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: token.Name, Namespace: token.Namespace}})
+	assert.Nil(t, err)
+
+	assert.Equal(t, []revocation{{endpoint: "https://argocd.example.com", iat: oldIAT}}, clients.revoked)
+
+	var secret corev1.Secret
+	assert.Nil(t, reconciler.Get(ctx, types.NamespacedName{Name: "argocd-token", Namespace: token.Namespace}, &secret))
+	assert.NotContains(t, secret.Data, "token")
+	assert.NotEmpty(t, secret.StringData["token-eu"])
+
+	stored := getTestToken(t, reconciler, token)
+	assert.Equal(t, 1, len(stored.Status.Instances))
+	assert.Equal(t, "https://eu.argocd.example.com", stored.Status.Instances[0].ArgoCDEndpt)
+}
+
+// listedIssuedAts returns when the tokens of the ci role the fake Argo CD lists were issued
+func listedIssuedAts(t *testing.T, argoCD argocd.ClientFactory) []int64 {
+	argoCDClient, err := argoCD.Client(context.Background(), *testToken(3600))
+	assert.Nil(t, err)
+	tokens, err := argoCDClient.ListTokens(context.Background())
+	assert.Nil(t, err)
+	iats := make([]int64, 0, len(tokens))
+	for _, tkn := range tokens {
+		iats = append(iats, tkn.IssuedAt)
+	}
+	return iats
+}
+
+func TestPruneInstancesOnSwitchToSingleEndpoint(t *testing.T) {
+	ctx := context.Background()
+	token := testToken(3600)
+	reconciler, argoCD := newTestReconciler(t)
+	euTkn, euIAT := issueTestToken(t, argoCD, *token)
+	token.Status.Instances = []argoprojlabsv1.InstanceStatus{
+		{Name: "eu", ArgoCDEndpt: "https://eu.argocd.example.com", Key: "token-eu"},
+	}
+
+	assert.Nil(t, reconciler.Create(ctx, testHubToken(t, token)))
+	assert.Nil(t, reconciler.Create(ctx, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "argocd-token", Namespace: token.Namespace},
+		Data:       map[string][]byte{"token-eu": []byte(euTkn)},
+	}))
+	clients := &revokingClients{ClientFactory: argoCD}
+	reconciler.Clients = clients
+
+	_, err := reconciler.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: token.Name, Namespace: token.Namespace}})
+	assert.Nil(t, err)
+	assert.Equal(t, []revocation{{endpoint: "https://eu.argocd.example.com", iat: euIAT}}, clients.revoked)
+
+	var secret corev1.Secret
+	assert.Nil(t, reconciler.Get(ctx, types.NamespacedName{Name: "argocd-token", Namespace: token.Namespace}, &secret))
+	assert.NotContains(t, secret.Data, "token-eu")
+	assert.NotEmpty(t, storedToken(t, reconciler, token))
+
+	stored := getTestToken(t, reconciler, token)
+	assert.Empty(t, stored.Status.Instances)
+}
+
+// staleSecretsClient never finds a Secret, like a cache that hasn't seen the Secrets created meanwhile
+type staleSecretsClient struct {
+	client.Client
+}
+
+func (c staleSecretsClient) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	if _, ok := obj.(*corev1.Secret); ok {
+		return apierrors.NewNotFound(corev1.Resource("secrets"), key.Name)
+	}
+	return c.Client.Get(ctx, key, obj)
+}
+
+func TestInstancesShareSecretCreatedMeanwhile(t *testing.T) {
+	ctx := context.Background()
+	token := testToken(3600)
+	token.Spec.ArgoCDEndpt = ""
+	token.Spec.Instances = []argoprojlabsv1.ArgoCDInstance{
+		{Name: "eu", ArgoCDEndpt: "https://eu.argocd.example.com"},
+		{Name: "us", ArgoCDEndpt: "https://us.argocd.example.com"},
+	}
+	reconciler, argoCD := newTestReconciler(t, token)
+	apiServer := reconciler.Client
+	reconciler.Client = staleSecretsClient{Client: apiServer}
+	clients := &revokingClients{ClientFactory: argoCD}
+	reconciler.Clients = clients
+
+	// the us instance still finds no Secret after the eu instance created it, so it adds its token to it
+	_, err := reconciler.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: token.Name, Namespace: token.Namespace}})
+	assert.Nil(t, err)
+
+	var secret corev1.Secret
+	assert.Nil(t, apiServer.Get(ctx, types.NamespacedName{Name: "argocd-token", Namespace: token.Namespace}, &secret))
+	assert.NotEmpty(t, secret.StringData["token-eu"])
+	assert.NotEmpty(t, secret.StringData["token-us"])
+	assert.Empty(t, clients.revoked)
+	assert.Equal(t, 2, len(listedIssuedAts(t, argoCD)))
+}
+
+func TestUnstoredTokenRevoked(t *testing.T) {
+	token := testToken(3600)
+	reconciler, argoCD := newTestReconciler(t, token)
+	reconciler.Client = &patchWatchingClient{Client: staleSecretsClient{Client: reconciler.Client}, onPatch: func() error {
+		return errors.New("the Secret can't be patched")
+	}}
+	assert.Nil(t, reconciler.Create(context.Background(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "argocd-token", Namespace: token.Namespace},
+	}))
+	clients := &revokingClients{ClientFactory: argoCD}
+	reconciler.Clients = clients
+
+	// the token minted for a Secret that can be neither created nor patched doesn't stay valid in Argo CD
+	result, err := reconciler.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: token.Name, Namespace: token.Namespace}})
+	assert.Nil(t, err)
+	assert.True(t, result.Requeue)
+	assert.Equal(t, 1, len(clients.revoked))
+	assert.Empty(t, listedIssuedAts(t, argoCD))
+}
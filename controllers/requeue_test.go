@@ -0,0 +1,25 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestEveryTokenRequeuedForRotation(t *testing.T) {
+	token := testToken(3600)
+	reconciler, _ := newTestReconciler(t, token)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: token.Name, Namespace: token.Namespace}}
+
+	// a Token is requeued for when its token is due, not only the controller's own Token
+	result, err := reconciler.Reconcile(req)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, storedToken(t, reconciler.Client, token))
+	assert.True(t, result.RequeueAfter > 0)
+
+	result, err = reconciler.Reconcile(req)
+	assert.Nil(t, err)
+	assert.True(t, result.RequeueAfter > 0)
+}
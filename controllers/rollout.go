@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	argoprojlabsv1 "github.com/argoproj-labs/argo-cd-tokens/api/v1"
+)
+
+// tokenHash identifies a token in pod template annotations without revealing it
+func tokenHash(jwtTkn string) string {
+	sum := sha256.Sum256([]byte(jwtTkn))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// restartRolloutTargets annotates the pod templates of the Token's rollout targets with the hash of the new token,
+// which makes their controllers roll out new pods. Failures are only logged, the token has already been rotated.
+func (r *TokenReconciler) restartRolloutTargets(ctx context.Context, token argoprojlabsv1.Token, jwtTkn string, logCtx logr.Logger) {
+	if len(token.Spec.RolloutTargets) == 0 {
+		return
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"annotations": map[string]string{argoprojlabsv1.TokenHashAnnotation: tokenHash(jwtTkn)},
+				},
+			},
+		},
+	})
+	if err != nil {
+		logCtx.Info(err.Error())
+		return
+	}
+
+	for _, target := range token.Spec.RolloutTargets {
+		workloads, err := r.rolloutWorkloads(ctx, token.Namespace, target)
+		if err != nil {
+			logCtx.Info(err.Error())
+			continue
+		}
+		for _, workload := range workloads {
+			err = r.Patch(ctx, workload, client.ConstantPatch(types.MergePatchType, patch))
+			if err != nil {
+				logCtx.Info(err.Error())
+				continue
+			}
+			logCtx.Info(fmt.Sprintf("Restarting %s %s", target.Kind, workloadName(workload)))
+		}
+	}
+}
+
+// workloadName returns the name of a workload returned by rolloutWorkloads
+func workloadName(workload runtime.Object) string {
+	if meta, ok := workload.(metav1.Object); ok {
+		return meta.GetName()
+	}
+	return ""
+}
+
+// rolloutWorkloads returns the workloads in namespace selected by target, read without the cache since the controller
+// has no permission to watch workloads
+func (r *TokenReconciler) rolloutWorkloads(ctx context.Context, namespace string, target argoprojlabsv1.RolloutTarget) ([]runtime.Object, error) {
+	if target.Name != "" {
+		var workload runtime.Object
+		switch target.Kind {
+		case "Deployment":
+			workload = &appsv1.Deployment{}
+		case "StatefulSet":
+			workload = &appsv1.StatefulSet{}
+		case "DaemonSet":
+			workload = &appsv1.DaemonSet{}
+		default:
+			return nil, fmt.Errorf("Rollout target kind %s is not supported", target.Kind)
+		}
+		err := r.Workloads.Get(ctx, types.NamespacedName{Name: target.Name, Namespace: namespace}, workload)
+		if err != nil {
+			return nil, err
+		}
+		return []runtime.Object{workload}, nil
+	}
+
+	if target.Selector == nil {
+		return nil, fmt.Errorf("Rollout target %s needs a name or a selector", target.Kind)
+	}
+	selector, err := metav1.LabelSelectorAsSelector(target.Selector)
+	if err != nil {
+		return nil, err
+	}
+	listOpts := client.UseListOptions(&client.ListOptions{LabelSelector: selector, Namespace: namespace})
+
+	var workloads []runtime.Object
+	switch target.Kind {
+	case "Deployment":
+		var list appsv1.DeploymentList
+		if err := r.Workloads.List(ctx, &list, listOpts); err != nil {
+			return nil, err
+		}
+		for i := range list.Items {
+			workloads = append(workloads, &list.Items[i])
+		}
+	case "StatefulSet":
+		var list appsv1.StatefulSetList
+		if err := r.Workloads.List(ctx, &list, listOpts); err != nil {
+			return nil, err
+		}
+		for i := range list.Items {
+			workloads = append(workloads, &list.Items[i])
+		}
+	case "DaemonSet":
+		var list appsv1.DaemonSetList
+		if err := r.Workloads.List(ctx, &list, listOpts); err != nil {
+			return nil, err
+		}
+		for i := range list.Items {
+			workloads = append(workloads, &list.Items[i])
+		}
+	default:
+		return nil, fmt.Errorf("Rollout target kind %s is not supported", target.Kind)
+	}
+	return workloads, nil
+}
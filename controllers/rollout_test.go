@@ -0,0 +1,34 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	argoprojlabsv1 "github.com/argoproj-labs/argo-cd-tokens/api/v1"
+)
+
+func TestRolloutWorkloadsReadUncached(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.Nil(t, appsv1.AddToScheme(scheme))
+	reconciler, _ := newTestReconciler(t)
+	// the manager's cache doesn't hold workloads, they are read straight from the API server
+	reconciler.Workloads = fakeclient.NewFakeClientWithScheme(scheme, &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", Labels: map[string]string{"app": "ci"}},
+	})
+
+	workloads, err := reconciler.rolloutWorkloads(context.Background(), "default",
+		argoprojlabsv1.RolloutTarget{Kind: "Deployment", Name: "app"})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(workloads))
+
+	workloads, err = reconciler.rolloutWorkloads(context.Background(), "default",
+		argoprojlabsv1.RolloutTarget{Kind: "Deployment", Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "ci"}}})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(workloads))
+}
@@ -0,0 +1,225 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	argoprojlabsv1 "github.com/argoproj-labs/argo-cd-tokens/api/v1"
+	"github.com/argoproj-labs/argo-cd-tokens/utils/jwt"
+)
+
+// newRotationReconciler returns a reconciler whose fake clock starts when the returned token was issued
+func newRotationReconciler(t *testing.T) (*TokenReconciler, *clock.FakeClock, *argoprojlabsv1.Token, string) {
+	ctx := context.Background()
+	token := testToken(3600)
+	reconciler, argoCD := newTestReconciler(t, token)
+
+	// tokens are issued on the real clock, the reconciler's clock starts when the token was issued
+	argoCDClient, err := argoCD.Client(ctx, *token)
+	assert.Nil(t, err)
+	project, err := argoCDClient.GetProject(ctx)
+	assert.Nil(t, err)
+	jwtTkn, err := argoCDClient.GenerateToken(ctx, project)
+	assert.Nil(t, err)
+	claims, err := jwt.ParseClaims(jwtTkn)
+	assert.Nil(t, err)
+
+	fakeClock := clock.NewFakeClock(time.Unix(claims.IssuedAt, 0))
+	reconciler.Clock = fakeClock
+	reconciler.ClockSkew = time.Minute
+	return reconciler, fakeClock, token, jwtTkn
+}
+
+func TestRotationPlannedForExpiry(t *testing.T) {
+	ctx := context.Background()
+	reconciler, fakeClock, token, jwtTkn := newRotationReconciler(t)
+	issued := fakeClock.Now()
+
+	// a Token is requeued for when its token expires, less the clock skew
+	assert.Equal(t, 59*time.Minute, reconciler.planRotation(ctx, token, jwtTkn, reconciler.Log).RequeueAfter)
+	assert.Equal(t, issued.Add(59*time.Minute).UTC().Format(time.RFC3339), token.Status.NextRotationAt)
+
+	fakeClock.Step(30 * time.Minute)
+	assert.Equal(t, 29*time.Minute, reconciler.planRotation(ctx, token, jwtTkn, reconciler.Log).RequeueAfter)
+
+	claims, _ := jwt.ParseClaims(jwtTkn)
+	assert.False(t, reconciler.expiry().Expired(claims))
+	fakeClock.Step(29 * time.Minute)
+	assert.True(t, reconciler.expiry().Expired(claims))
+}
+
+func TestRotationPlannedForRenewPercent(t *testing.T) {
+	ctx := context.Background()
+	reconciler, fakeClock, token, jwtTkn := newRotationReconciler(t)
+	reconciler.Defaults.RenewPercent = 80
+
+	// the token is rotated once it used up 80% of its lifetime, less the clock skew
+	assert.Equal(t, 47*time.Minute, reconciler.planRotation(ctx, token, jwtTkn, reconciler.Log).RequeueAfter)
+
+	claims, _ := jwt.ParseClaims(jwtTkn)
+	fakeClock.Step(46 * time.Minute)
+	assert.False(t, reconciler.expiry().Expired(claims))
+	fakeClock.Step(time.Minute)
+	assert.True(t, reconciler.expiry().Expired(claims))
+}
+
+// patchWatchingClient calls onPatch before patching an object, failing the patch if it returns an error
+type patchWatchingClient struct {
+	client.Client
+	onPatch func() error
+}
+
+func (c *patchWatchingClient) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOptionFunc) error {
+	if err := c.onPatch(); err != nil {
+		return err
+	}
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func TestRenewalKeepsOldTokenUntilSecretPatched(t *testing.T) {
+	ctx := context.Background()
+	reconciler, fakeClock, token, jwtTkn := newRotationReconciler(t)
+	reconciler.Defaults.RenewPercent = 80
+	claims, _ := jwt.ParseClaims(jwtTkn)
+	assert.Nil(t, reconciler.Create(ctx, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "argocd-token", Namespace: token.Namespace},
+		Data:       map[string][]byte{"token": []byte(jwtTkn)},
+	}))
+	argoCD := reconciler.Clients
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: token.Name, Namespace: token.Namespace}}
+
+	// the token is due for renewal but still valid
+	fakeClock.Step(50 * time.Minute)
+
+	// while the Secret can't be patched the old token stays valid
+	reconciler.Client = &patchWatchingClient{Client: reconciler.Client, onPatch: func() error {
+		return errors.New("the Secret can't be patched")
+	}}
+	_, err := reconciler.Reconcile(request)
+	assert.Nil(t, err)
+	assert.Contains(t, listedIssuedAts(t, argoCD), claims.IssuedAt)
+	assert.Equal(t, jwtTkn, storedToken(t, reconciler, token))
+
+	// the old token is still accepted when the Secret is patched, and revoked only afterwards
+	var listedWhenPatched []int64
+	reconciler.Client = &patchWatchingClient{Client: reconciler.Client.(*patchWatchingClient).Client, onPatch: func() error {
+		listedWhenPatched = listedIssuedAts(t, argoCD)
+		return nil
+	}}
+	clients := &revokingClients{ClientFactory: argoCD}
+	reconciler.Clients = clients
+	_, err = reconciler.Reconcile(request)
+	assert.Nil(t, err)
+	assert.Contains(t, listedWhenPatched, claims.IssuedAt)
+	assert.Equal(t, []revocation{{endpoint: token.Spec.ArgoCDEndpt, iat: claims.IssuedAt}}, clients.revoked)
+}
+
+func TestRotationPlannedForSelfRenewal(t *testing.T) {
+	ctx := context.Background()
+	reconciler, fakeClock, token, jwtTkn := newRotationReconciler(t)
+	reconciler.SelfToken = types.NamespacedName{Name: token.Name, Namespace: token.Namespace}
+
+	// the controller's own credential is requeued for its renewal point
+	assert.Equal(t, 48*time.Minute, reconciler.planRotation(ctx, token, jwtTkn, reconciler.Log).RequeueAfter)
+	fakeClock.Step(50 * time.Minute)
+	assert.Zero(t, reconciler.planRotation(ctx, token, jwtTkn, reconciler.Log).RequeueAfter)
+}
+
+func TestRotationPlannedForSchedule(t *testing.T) {
+	ctx := context.Background()
+	reconciler, fakeClock, token, _ := newRotationReconciler(t)
+
+	// windows open every ten minutes and stay open for five, the first ten minutes of each half hour are blacked out
+	token.Spec.RotationSchedule = &argoprojlabsv1.RotationSchedule{
+		Cron:      "*/10 * * * *",
+		Window:    300,
+		Blackouts: []argoprojlabsv1.BlackoutWindow{{Cron: "0,30 * * * *", Duration: 600}},
+	}
+	fakeClock.SetTime(fakeClock.Now().Truncate(time.Hour).Add(time.Hour))
+	jwtTkn := signedTestToken(t, fakeClock.Now(), time.Hour)
+
+	// the token has to be replaced by minute 59, the window at minute 50 is the last one
+	assert.Equal(t, 50*time.Minute, reconciler.planRotation(ctx, token, jwtTkn, reconciler.Log).RequeueAfter)
+	assert.Equal(t, fakeClock.Now().Add(50*time.Minute).UTC().Format(time.RFC3339), token.Status.NextRotationAt)
+
+	// a deadline inside a blackout moves the rotation to the window before it
+	token.Spec.RotationSchedule.Blackouts = append(token.Spec.RotationSchedule.Blackouts,
+		argoprojlabsv1.BlackoutWindow{Cron: "50 * * * *", Duration: 600})
+	assert.Equal(t, 40*time.Minute, reconciler.planRotation(ctx, token, jwtTkn, reconciler.Log).RequeueAfter)
+
+	// an invalid schedule falls back to rotating on expiry
+	token.Spec.RotationSchedule.Cron = "never"
+	assert.Equal(t, 59*time.Minute, reconciler.planRotation(ctx, token, jwtTkn, reconciler.Log).RequeueAfter)
+}
+
+func TestRotationRequested(t *testing.T) {
+	logCtx := newTestLogger()
+	request := func(rotateAt string, lastRotateAt string) string {
+		var token argoprojlabsv1.Token
+		if rotateAt != "" {
+			token.Annotations = map[string]string{argoprojlabsv1.RotateAtAnnotation: rotateAt}
+		}
+		token.Status.LastRotateAt = lastRotateAt
+		return rotationRequested(token, logCtx)
+	}
+
+	// a rotation is asked for when rotate-at is newer than the last handled one
+	assert.Empty(t, request("", ""))
+	assert.Equal(t, "2019-08-05T10:00:00Z", request("2019-08-05T10:00:00Z", ""))
+	assert.Empty(t, request("2019-08-05T10:00:00Z", "2019-08-05T10:00:00Z"))
+	assert.Equal(t, "2019-08-05T11:00:00Z", request("2019-08-05T11:00:00Z", "2019-08-05T10:00:00Z"))
+	assert.Empty(t, request("2019-08-05T09:00:00Z", "2019-08-05T10:00:00Z"))
+	assert.Empty(t, request("yesterday", ""))
+}
+
+// conflictingClient fails the first status updates with a conflict, as if the Token was changed meanwhile
+type conflictingClient struct {
+	client.Client
+	conflicts int
+}
+
+func (c *conflictingClient) Status() client.StatusWriter {
+	return &conflictingStatusWriter{StatusWriter: c.Client.Status(), client: c}
+}
+
+type conflictingStatusWriter struct {
+	client.StatusWriter
+	client *conflictingClient
+}
+
+func (w *conflictingStatusWriter) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOptionFunc) error {
+	if w.client.conflicts > 0 {
+		w.client.conflicts--
+		return apierrors.NewConflict(argoprojlabsv1.GroupVersion.WithResource("tokens").GroupResource(), "ci-token",
+			errors.New("the object has been modified"))
+	}
+	return w.StatusWriter.Update(ctx, obj, opts...)
+}
+
+func TestRotationRecordedDespiteConflicts(t *testing.T) {
+	ctx := context.Background()
+	token := testToken(3600)
+	reconciler, _ := newTestReconciler(t, token)
+	reconciler.Client = &conflictingClient{Client: reconciler.Client, conflicts: 2}
+
+	// the handled rotate-at survives a conflicting status write, or the token would be rotated again
+	reconciler.rotated(ctx, token, "2019-08-05T10:00:00.5Z", reconciler.Log)
+	stored := getTestToken(t, reconciler, token)
+	assert.Equal(t, "2019-08-05T10:00:00.5Z", stored.Status.LastRotateAt)
+
+	// rotate-at is compared below the second
+	stored.Annotations = map[string]string{argoprojlabsv1.RotateAtAnnotation: "2019-08-05T10:00:00.75Z"}
+	assert.Equal(t, "2019-08-05T10:00:00.75Z", rotationRequested(stored, reconciler.Log))
+}
@@ -0,0 +1,34 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+
+	argoprojlabsv1 "github.com/argoproj-labs/argo-cd-tokens/api/v1"
+)
+
+func TestSecretTokens(t *testing.T) {
+	token := func(namespace string, name string, secretName string) *argoprojlabsv1.Token {
+		return &argoprojlabsv1.Token{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       argoprojlabsv1.TokenSpec{SecretRef: argoprojlabsv1.SecretReference{Name: secretName, Key: "token"}},
+		}
+	}
+	reconciler, _ := newTestReconciler(t,
+		token("team-a", "ci", "argocd-token"),
+		token("team-a", "deploy", "deploy-token"),
+		token("team-b", "ci", "argocd-token"),
+	)
+
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "argocd-token", Namespace: "team-a"}}
+	requests := reconciler.secretTokens(handler.MapObject{Meta: secret, Object: secret})
+
+	// the Token of the same Secret name in team-b is left alone
+	assert.Equal(t, 1, len(requests))
+	assert.Equal(t, types.NamespacedName{Name: "ci", Namespace: "team-a"}, requests[0].NamespacedName)
+}
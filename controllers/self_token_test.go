@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
+	ctrl "sigs.k8s.io/controller-runtime"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	argoprojlabsv1 "github.com/argoproj-labs/argo-cd-tokens/api/v1"
+	argoprojlabsv2 "github.com/argoproj-labs/argo-cd-tokens/api/v2"
+	"github.com/argoproj-labs/argo-cd-tokens/utils/argocd"
+	"github.com/argoproj-labs/argo-cd-tokens/utils/argocd/fake"
+	"github.com/argoproj-labs/argo-cd-tokens/utils/jwt"
+)
+
+func TestRejectedSelfTokenIsReplaced(t *testing.T) {
+	ctx := context.Background()
+	endpoint := "https://argocd.example.com"
+	selfName := types.NamespacedName{Name: "controller", Namespace: "argo-cd-tokens-system"}
+
+	argoCD := fake.NewArgoCD(fake.NewProject("token-controller", "controller"))
+	expiresIn := 3600
+	token := argoprojlabsv1.Token{
+		ObjectMeta: metav1.ObjectMeta{Name: selfName.Name, Namespace: selfName.Namespace},
+		Spec: argoprojlabsv1.TokenSpec{
+			ArgoCDEndpt: endpoint,
+			Project:     "token-controller",
+			Role:        "controller",
+			ExpiresIn:   &expiresIn,
+			SecretRef:   argoprojlabsv1.SecretReference{Name: "controller-credential", Key: "authTkn"},
+		},
+	}
+	argoCDClient, err := argoCD.Client(ctx, token)
+	assert.Nil(t, err)
+	project, err := argoCDClient.GetProject(ctx)
+	assert.Nil(t, err)
+	oldTkn, err := argoCDClient.GenerateToken(ctx, project)
+	assert.Nil(t, err)
+	oldClaims, err := jwt.ParseClaims(oldTkn)
+	assert.Nil(t, err)
+
+	scheme := runtime.NewScheme()
+	assert.Nil(t, corev1.AddToScheme(scheme))
+	assert.Nil(t, argoprojlabsv2.AddToScheme(scheme))
+	k8sClient := fakeclient.NewFakeClientWithScheme(scheme, testHubToken(t, &token), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "controller-credential", Namespace: selfName.Namespace},
+		Data:       map[string][]byte{"authTkn": []byte(oldTkn)},
+	})
+
+	selfAuth := argocd.NewSelfManagedAuth(argocd.SelfTokenSource(k8sClient, selfName, argoprojlabsv1.TokenSettings{}), argocd.NewStaticAuth("bootstrap"))
+	reconciler := &TokenReconciler{
+		Client:       k8sClient,
+		Log:          newTestLogger(),
+		Auth:         selfAuth,
+		Breakers:     argocd.NewCircuitBreakers(5, 30*time.Second),
+		Clients:      argoCD,
+		SelfToken:    selfName,
+		Clock:        clock.RealClock{},
+		authFailures: make(map[types.NamespacedName]struct{}),
+		retryAuth:    make(chan event.GenericEvent),
+	}
+	selfAuth.OnReject(reconciler.RenewSelfToken)
+
+	// the fresh credential is kept while Argo CD accepts it
+	_, err = reconciler.Reconcile(ctrl.Request{NamespacedName: selfName})
+	assert.Nil(t, err)
+	tokens, _ := argoCDClient.ListTokens(ctx)
+	assert.Equal(t, 1, len(tokens))
+
+	// once Argo CD rejects it the Token is enqueued and its token replaced before it expires
+	assert.True(t, selfAuth.Invalidate(endpoint))
+	select {
+	case evt := <-reconciler.retryAuth:
+		assert.Equal(t, selfName.Name, evt.Meta.GetName())
+		assert.Equal(t, selfName.Namespace, evt.Meta.GetNamespace())
+	case <-time.After(time.Second):
+		t.Fatal("the self Token was not enqueued")
+	}
+
+	_, err = reconciler.Reconcile(ctrl.Request{NamespacedName: selfName})
+	assert.Nil(t, err)
+	tokens, _ = argoCDClient.ListTokens(ctx)
+	assert.Equal(t, 1, len(tokens))
+	assert.NotEqual(t, oldClaims.IssuedAt, tokens[0].IssuedAt)
+
+	var secret corev1.Secret
+	assert.Nil(t, k8sClient.Get(ctx, types.NamespacedName{Name: "controller-credential", Namespace: selfName.Namespace}, &secret))
+	newClaims, err := jwt.ParseClaims(secret.StringData["authTkn"])
+	assert.Nil(t, err)
+	assert.Equal(t, tokens[0].IssuedAt, newClaims.IssuedAt)
+}
@@ -16,6 +16,7 @@ limitations under the License.
 package controllers
 
 import (
+	"context"
 	"path/filepath"
 	"testing"
 
@@ -23,8 +24,13 @@ import (
 	. "github.com/onsi/gomega"
 
 	argoprojlabsv1 "github.com/argoproj-labs/argo-cd-tokens/api/v1"
+	argoprojlabsv2 "github.com/argoproj-labs/argo-cd-tokens/api/v2"
+	"github.com/argoproj-labs/argo-cd-tokens/utils/argocd"
+	"github.com/argoproj-labs/argo-cd-tokens/utils/argocd/fake"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -39,6 +45,14 @@ var cfg *rest.Config
 var k8sClient client.Client
 var testEnv *envtest.Environment
 
+// argoCD is the fake Argo CD the reconciler under test talks to through argoCDServer
+var argoCD *fake.ArgoCD
+var argoCDServer *fake.Server
+var stopMgr chan struct{}
+
+// controllerAuthTkn is the credential the reconciler under test logs into the fake Argo CD with
+const controllerAuthTkn = "controller-token"
+
 func TestAPIs(t *testing.T) {
 	RegisterFailHandler(Fail)
 
@@ -55,24 +69,115 @@ var _ = BeforeSuite(func(done Done) {
 		CRDDirectoryPaths: []string{filepath.Join("..", "config", "crd", "bases")},
 	}
 
-	cfg, err := testEnv.Start()
+	var err error
+	cfg, err = testEnv.Start()
 	Expect(err).ToNot(HaveOccurred())
 	Expect(cfg).ToNot(BeNil())
 
 	err = argoprojlabsv1.AddToScheme(scheme.Scheme)
 	Expect(err).NotTo(HaveOccurred())
+	err = argoprojlabsv2.AddToScheme(scheme.Scheme)
+	Expect(err).NotTo(HaveOccurred())
 
 	// +kubebuilder:scaffold:scheme
 
-	k8sClient, err = client.New(cfg, client.Options{Scheme: scheme.Scheme})
+	apiClient, err := client.New(cfg, client.Options{Scheme: scheme.Scheme})
 	Expect(err).ToNot(HaveOccurred())
+	k8sClient = hubClient{Client: apiClient}
 	Expect(k8sClient).ToNot(BeNil())
 
+	argoCD = fake.NewArgoCD(fake.NewProject("default", "ci", "deploy"))
+	argoCDServer = fake.NewServer(argoCD, controllerAuthTkn)
+
+	mgr, err := ctrl.NewManager(cfg, ctrl.Options{
+		Scheme:             scheme.Scheme,
+		MetricsBindAddress: "0",
+	})
+	Expect(err).ToNot(HaveOccurred())
+
+	err = (&TokenReconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("Token"),
+		Auth:   argocd.NewStaticAuth(controllerAuthTkn),
+		SigningKey: func(ctx context.Context) ([]byte, error) {
+			return argoCD.SigningKey, nil
+		},
+	}).SetupWithManager(mgr)
+	Expect(err).ToNot(HaveOccurred())
+
+	stopMgr = make(chan struct{})
+	go func() {
+		defer GinkgoRecover()
+		Expect(mgr.Start(stopMgr)).To(Succeed())
+	}()
+
 	close(done)
 }, 60)
 
 var _ = AfterSuite(func() {
 	By("tearing down the test environment")
+	close(stopMgr)
+	argoCDServer.Close()
 	err := testEnv.Stop()
 	Expect(err).ToNot(HaveOccurred())
 })
+
+// hubClient lets the specs work with v1 Tokens while they go to the API server in v2, the version the controller reads
+// them in, as envtest doesn't serve the conversion webhook
+type hubClient struct {
+	client.Client
+}
+
+func (c hubClient) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	token, ok := obj.(*argoprojlabsv1.Token)
+	if !ok {
+		return c.Client.Get(ctx, key, obj)
+	}
+	var stored argoprojlabsv2.Token
+	if err := c.Client.Get(ctx, key, &stored); err != nil {
+		return err
+	}
+	return token.ConvertFrom(&stored)
+}
+
+func (c hubClient) Create(ctx context.Context, obj runtime.Object, opts ...client.CreateOptionFunc) error {
+	token, ok := obj.(*argoprojlabsv1.Token)
+	if !ok {
+		return c.Client.Create(ctx, obj, opts...)
+	}
+	stored, err := hubToken(token)
+	if err != nil {
+		return err
+	}
+	if err := c.Client.Create(ctx, stored, opts...); err != nil {
+		return err
+	}
+	return token.ConvertFrom(stored)
+}
+
+func (c hubClient) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOptionFunc) error {
+	token, ok := obj.(*argoprojlabsv1.Token)
+	if !ok {
+		return c.Client.Update(ctx, obj, opts...)
+	}
+	stored, err := hubToken(token)
+	if err != nil {
+		return err
+	}
+	if err := c.Client.Update(ctx, stored, opts...); err != nil {
+		return err
+	}
+	return token.ConvertFrom(stored)
+}
+
+func (c hubClient) Delete(ctx context.Context, obj runtime.Object, opts ...client.DeleteOptionFunc) error {
+	token, ok := obj.(*argoprojlabsv1.Token)
+	if !ok {
+		return c.Client.Delete(ctx, obj, opts...)
+	}
+	stored, err := hubToken(token)
+	if err != nil {
+		return err
+	}
+	return c.Client.Delete(ctx, stored, opts...)
+}
@@ -19,23 +19,35 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
 	ctrl "sigs.k8s.io/controller-runtime"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	argoprojlabsv1 "github.com/argoproj-labs/argo-cd-tokens/api/v1"
+	argoprojlabsv2 "github.com/argoproj-labs/argo-cd-tokens/api/v2"
 	"github.com/argoproj-labs/argo-cd-tokens/utils/argocd"
 	"github.com/argoproj-labs/argo-cd-tokens/utils/jwt"
+	"github.com/argoproj-labs/argo-cd-tokens/utils/schedule"
+	"github.com/argoproj-labs/argo-cd-tokens/utils/shard"
 )
 
 const (
@@ -44,13 +56,54 @@ const (
 			"%s": "%s"
 	}
 }`
+
+	// selfRenewPercent is how far into its lifetime the controller's own credential is renewed
+	selfRenewPercent = 80
 )
 
 // TokenReconciler reconciles a Token object
 type TokenReconciler struct {
 	client.Client
-	Log     logr.Logger
-	authTkn string
+	Log  logr.Logger
+	Auth argocd.AuthProvider
+	// ArgoCDOptions configures the timeouts and retries of calls to Argo CD
+	ArgoCDOptions argocd.Options
+	// Breakers pause calls to Argo CD endpoints that keep failing
+	Breakers *argocd.CircuitBreakers
+	// Clients hands out the Argo CD client for a Token, by default sharing pooled connections between reconciles
+	Clients argocd.ClientFactory
+	// SelfToken names the Token holding the controller's own Argo CD credential, if it manages one
+	SelfToken types.NamespacedName
+	// VerifyTokens replaces tokens in Secrets that were not issued by Argo CD for the Token's project and role
+	VerifyTokens bool
+	// SigningKey loads the key Argo CD signs tokens with, to verify signatures as well. It may be nil.
+	SigningKey argocd.SigningKeyFunc
+	// Clock decides when tokens expire, it defaults to the real clock
+	Clock clock.Clock
+	// ClockSkew is how far the controller's clock may be off from Argo CD's. Tokens are rotated this much early.
+	ClockSkew time.Duration
+	// DryRun makes the controller only record what it would do for every Token, see TokenSpec.DryRun
+	DryRun bool
+	// Recorder emits the Events of dry runs, it defaults to the manager's recorder
+	Recorder record.EventRecorder
+	// Workloads reads the workloads of rollout targets. It defaults to the manager's API reader: workloads are read
+	// rarely, and caching them would need a watch on every workload the controller may restart.
+	Workloads client.Reader
+	// Shard selects the Tokens this replica reconciles when several replicas split them, by default all of them
+	Shard shard.Shard
+	// MaxConcurrentReconciles is how many Tokens are reconciled at once, it defaults to one
+	MaxConcurrentReconciles int
+	// Projects reads projects from the AppProjects of an Argo CD in the same cluster, which are then watched as
+	// well. It may be nil.
+	Projects *argocd.ClusterProjects
+	// Defaults are merged under the spec of every Token, see argoprojlabsv1.TokenControllerConfig
+	Defaults argoprojlabsv1.TokenSettings
+
+	// authFailures holds the Tokens whose last reconcile was rejected by Argo CD for authentication
+	authFailures   map[types.NamespacedName]struct{}
+	authFailuresMu sync.Mutex
+	// retryAuth feeds Tokens to be reconciled again once the controller credential changed
+	retryAuth chan event.GenericEvent
 }
 
 // Defines our Patch object we use for updating Secrets
@@ -71,132 +124,684 @@ func (p *patchSecretKey) Data(obj runtime.Object) ([]byte, error) {
 // Reconcile checks if our Secret exists and generates a new Secret or updates a current one
 // +kubebuilder:rbac:groups=argoprojlabs.argoproj-labs.io,resources=tokens,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=argoprojlabs.argoproj-labs.io,resources=tokens/status,verbs=get;update;patch
-// +kubebuilder:rbac:resources=secrets,verbs=get;patch;create;list;watch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;update;patch;create;list;watch
+// +kubebuilder:rbac:groups=apps,resources=deployments;statefulsets;daemonsets,verbs=get;list;patch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups=argoproj.io,resources=appprojects,verbs=get;list;watch
 func (r *TokenReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	ctx := context.Background()
 	logCtx := r.Log.WithValues("token", req.NamespacedName)
 
+	r.authFailuresMu.Lock()
+	delete(r.authFailures, req.NamespacedName)
+	r.authFailuresMu.Unlock()
+
 	var token argoprojlabsv1.Token
 
 	// Fills token object and catches error if not possible
-	err := r.Get(ctx, req.NamespacedName, &token)
+	err := r.getToken(ctx, req.NamespacedName, &token)
 	if err != nil {
 		logCtx.Info(err.Error())
 		return ctrl.Result{}, nil
 	}
+	previous := token.Status.Effective
+	token = r.withDefaults(token)
+	r.recordEffective(ctx, &token, logCtx)
+
+	if token.Spec.Suspend {
+		r.suspended(ctx, &token, logCtx)
+		return ctrl.Result{}, nil
+	}
+	if condition := token.Status.GetCondition(argoprojlabsv1.TokenConditionSuspended); condition != nil &&
+		condition.Status == corev1.ConditionTrue {
+		// rotations that came due or were requested while suspended are caught up by this reconcile
+		logCtx.Info("Token resumed")
+		r.setCondition(ctx, &token, logCtx, argoprojlabsv1.TokenCondition{
+			Type:   argoprojlabsv1.TokenConditionSuspended,
+			Status: corev1.ConditionFalse,
+			Reason: "Resumed",
+		})
+	}
+
+	stale := r.pruneKeys(ctx, token, staleKeys(token, previous), logCtx)
+	if len(token.Spec.Instances) > 0 {
+		return r.reconcileInstances(ctx, &token, stale, logCtx)
+	}
+	if !equality.Semantic.DeepEqual(token.Status.Instances, stale) {
+		// the Token switched back from instances to a single endpoint
+		token.Status.Instances = stale
+		r.updateStatus(ctx, &token, logCtx)
+	}
+	result, err := r.reconcileToken(ctx, &token, logCtx)
+	result.Requeue = result.Requeue || r.pruneRetried(token, stale)
+	return result, err
+}
 
-	argoCDClient := argocd.NewArgoCDClient(r.authTkn, token)
+// reconcileToken issues, rotates and revokes the token of a Token, or of one of its Argo CD instances
+func (r *TokenReconciler) reconcileToken(ctx context.Context, token *argoprojlabsv1.Token, logCtx logr.Logger) (ctrl.Result, error) {
+	dryRun := r.isDryRun(*token)
+	if !dryRun {
+		r.recordPlan(ctx, token, logCtx, nil)
+	}
+
+	rotateAt := rotationRequested(*token, logCtx)
+
+	if openFor := r.Breakers.OpenFor(token.Spec.ArgoCDEndpt); openFor > 0 {
+		return r.argoCDUnavailable(ctx, token, logCtx, openFor)
+	}
 
-	project, err := argoCDClient.GetProject()
+	// Every call to Argo CD made by this reconcile shares one deadline so a hung connection can't block the worker
+	argoCtx, cancel := r.ArgoCDOptions.WithTimeout(ctx)
+	defer cancel()
+
+	directClient, err := r.Clients.Client(argoCtx, *token)
 	if err != nil {
-		logCtx.Info(err.Error())
-		return ctrl.Result{}, nil
+		return r.argoCDFailed(ctx, token, logCtx, err)
+	}
+	argoCDClient := directClient
+	cachedProject := r.Projects.Serves(token.Spec.ArgoCDEndpt)
+	if cachedProject {
+		argoCDClient = r.Projects.Client(directClient, *token)
+	}
+
+	project, err := argoCDClient.GetProject(argoCtx)
+	if err != nil {
+		return r.argoCDFailed(ctx, token, logCtx, err)
+	}
+
+	if token.Status.GetCondition(argoprojlabsv1.TokenConditionArgoCDUnavailable) != nil {
+		r.setCondition(ctx, token, logCtx, argoprojlabsv1.TokenCondition{
+			Type:   argoprojlabsv1.TokenConditionArgoCDUnavailable,
+			Status: corev1.ConditionFalse,
+			Reason: "ArgoCDRecovered",
+		})
 	}
 
 	namespaceName := types.NamespacedName{
 		Name:      token.Spec.SecretRef.Name,
 		Namespace: token.ObjectMeta.Namespace,
 	}
+	updateSecret := fmt.Sprintf("Update Secret %s with the new token", namespaceName)
 
 	var tknSecret corev1.Secret
 
 	err = r.Get(ctx, namespaceName, &tknSecret)
 	if err == nil {
 		jwtTkn := string(tknSecret.Data[token.Spec.SecretRef.Key])
-		isTokenExpired, err := jwt.TokenExpired(jwtTkn)
+		signingKey, err := r.signingKey(ctx)
 		if err != nil {
 			logCtx.Info(err.Error())
 			return ctrl.Result{}, nil
 		}
-		if isTokenExpired {
-			err = argoCDClient.DeleteToken(jwtTkn)
+		claims, err := r.verifyToken(*token, jwtTkn, signingKey)
+		if err != nil {
+			// The Secret doesn't hold a token Argo CD issued for the Token, so it is replaced without revoking it
+			logCtx.Info(err.Error())
+			if dryRun {
+				return r.planIssue(ctx, token, logCtx, project, updateSecret+", replacing one not issued for the Token", "", ""), nil
+			}
+			jwtTkn, err = argoCDClient.GenerateToken(argoCtx, project)
+			if err != nil {
+				return r.argoCDFailed(ctx, token, logCtx, err)
+			}
+			err = r.patchSecret(ctx, &tknSecret, jwtTkn, logCtx, *token)
+			if err != nil {
+				r.discardToken(argoCtx, argoCDClient, jwtTkn, logCtx)
+				return ctrl.Result{Requeue: true}, nil
+			}
+			logCtx.Info("Secret did not hold a valid token and was replaced!")
+			r.rotated(ctx, token, rotateAt, logCtx)
+			return r.planRotation(ctx, token, jwtTkn, logCtx), nil
+		}
+		if r.expiry().Expired(claims) && !claims.Expired(r.Clock.Now()) {
+			// The token is due for renewal but still valid, so consumers keep using it until the Secret holds the new one
+			if dryRun {
+				return r.planIssue(ctx, token, logCtx, project, updateSecret+", renewing a token about to expire", "", revokeAction(claims, "old ")), nil
+			}
+			newTkn, err := r.swapToken(ctx, argoCtx, argoCDClient, project, &tknSecret, *token, claims, logCtx)
+			if err != nil {
+				return r.argoCDFailed(ctx, token, logCtx, err)
+			}
+			logCtx.Info("Token renewed before it expired!")
+			r.rotated(ctx, token, rotateAt, logCtx)
+			return r.planRotation(ctx, token, newTkn, logCtx), nil
+		}
+		if r.expiry().Expired(claims) {
+			if dryRun {
+				return r.planIssue(ctx, token, logCtx, project, updateSecret, revokeAction(claims, "expired "), ""), nil
+			}
+			err = argoCDClient.DeleteTokenIssuedAt(argoCtx, claims.IssuedAt)
 			if err != nil {
-				logCtx.Info(err.Error())
-				return ctrl.Result{}, nil
+				return r.argoCDFailed(ctx, token, logCtx, err)
 			}
-			jwtTkn, err = argoCDClient.GenerateToken(project)
+			jwtTkn, err = argoCDClient.GenerateToken(argoCtx, project)
 			if err != nil {
-				logCtx.Info(err.Error())
-				return ctrl.Result{}, nil
+				return r.argoCDFailed(ctx, token, logCtx, err)
 			}
 			//token.Status.TokenIssuedAts = append(token.Status.TokenIssuedAts, jwt.ReturnIAT(jwtTkn))
 			//fmt.Println(token.Status.TokenIssuedAts)
-			err = r.patchSecret(ctx, &tknSecret, jwtTkn, logCtx, token)
+			err = r.patchSecret(ctx, &tknSecret, jwtTkn, logCtx, *token)
 			if err != nil {
-				logCtx.Info(err.Error())
-				return ctrl.Result{}, nil
+				r.discardToken(argoCtx, argoCDClient, jwtTkn, logCtx)
+				return ctrl.Result{Requeue: true}, nil
 			}
 			logCtx.Info("Secret successfully updated!")
-			scheduleReconcile := ctrl.Result{RequeueAfter: time.Duration(jwt.TimeTillExpire(jwtTkn)) * time.Second}
-			return scheduleReconcile, nil
+			r.rotated(ctx, token, rotateAt, logCtx)
+			return r.planRotation(ctx, token, jwtTkn, logCtx), nil
+		}
+
+		revoked := argocd.TokenRevoked(token.Spec.Role, claims.IssuedAt, project)
+		if revoked && cachedProject {
+			// The cached AppProject may not list a token issued moments ago yet, so Argo CD has the final say
+			project, err = directClient.GetProject(argoCtx)
+			if err != nil {
+				return r.argoCDFailed(ctx, token, logCtx, err)
+			}
+			revoked = argocd.TokenRevoked(token.Spec.Role, claims.IssuedAt, project)
+		}
+		if revoked {
+			// The token was revoked in Argo CD without the controller, so a new one takes its place. Its iat is
+			// deleted as well in case it is still listed somewhere, which Argo CD accepts if it isn't.
+			if dryRun {
+				return r.planIssue(ctx, token, logCtx, project, updateSecret+", replacing a revoked token", "", revokeAction(claims, "revoked ")), nil
+			}
+			newTkn, err := r.swapToken(ctx, argoCtx, argoCDClient, project, &tknSecret, *token, claims, logCtx)
+			if err != nil {
+				return r.argoCDFailed(ctx, token, logCtx, err)
+			}
+			logCtx.Info("Revoked token was replaced!")
+			r.rotated(ctx, token, rotateAt, logCtx)
+			return r.planRotation(ctx, token, newTkn, logCtx), nil
+		}
+
+		if r.isSelfToken(*token) && r.selfTokenRejected(jwtTkn) {
+			// Argo CD no longer accepts the controller's own credential, it runs on the bootstrap token until replaced
+			if dryRun {
+				r.planIssue(ctx, token, logCtx, project, updateSecret+", replacing the rejected controller credential", "", revokeAction(claims, "old "))
+				return r.planRotation(ctx, token, jwtTkn, logCtx), nil
+			}
+			newTkn, err := r.swapToken(ctx, argoCtx, argoCDClient, project, &tknSecret, *token, claims, logCtx)
+			if err != nil {
+				return r.argoCDFailed(ctx, token, logCtx, err)
+			}
+			logCtx.Info("Rejected controller credential replaced!")
+			return r.planRotation(ctx, token, newTkn, logCtx), nil
+		}
+
+		if rotateAt != "" {
+			if dryRun {
+				r.planIssue(ctx, token, logCtx, project, updateSecret+", rotating on request", "", revokeAction(claims, "old "))
+				return r.planRotation(ctx, token, jwtTkn, logCtx), nil
+			}
+			newTkn, err := r.swapToken(ctx, argoCtx, argoCDClient, project, &tknSecret, *token, claims, logCtx)
+			if err != nil {
+				return r.argoCDFailed(ctx, token, logCtx, err)
+			}
+			logCtx.Info("Token rotated on request!", "rotateAt", rotateAt)
+			r.rotated(ctx, token, rotateAt, logCtx)
+			return r.planRotation(ctx, token, newTkn, logCtx), nil
 		}
 
+		if r.isSelfToken(*token) && claims.Elapsed(r.Clock.Now())*100 >= selfRenewPercent {
+			// The controller's own credential is replaced before it expires
+			if dryRun {
+				r.planIssue(ctx, token, logCtx, project, updateSecret+", renewing the controller credential", "", revokeAction(claims, "old "))
+				return r.planRotation(ctx, token, jwtTkn, logCtx), nil
+			}
+			newTkn, err := r.swapToken(ctx, argoCtx, argoCDClient, project, &tknSecret, *token, claims, logCtx)
+			if err != nil {
+				return r.argoCDFailed(ctx, token, logCtx, err)
+			}
+			logCtx.Info("Controller credential renewed!")
+			return r.planRotation(ctx, token, newTkn, logCtx), nil
+		}
+
+		if token.Spec.RotationSchedule != nil {
+			next, err := r.nextRotation(*token, claims)
+			if err == nil && !next.IsZero() && !next.After(r.Clock.Now()) {
+				// The token would expire before the next rotation window, so it is rotated in this one
+				if dryRun {
+					return r.planIssue(ctx, token, logCtx, project, updateSecret+", rotating in the rotation window", "", revokeAction(claims, "old ")), nil
+				}
+				newTkn, err := r.swapToken(ctx, argoCtx, argoCDClient, project, &tknSecret, *token, claims, logCtx)
+				if err != nil {
+					return r.argoCDFailed(ctx, token, logCtx, err)
+				}
+				logCtx.Info("Token rotated in its rotation window!")
+				return r.planRotation(ctx, token, newTkn, logCtx), nil
+			}
+		}
+
+		if dryRun {
+			r.recordPlan(ctx, token, logCtx, nil)
+		}
 		logCtx.Info("Secret was not updated, token still valid")
-		return ctrl.Result{}, nil
+		return r.planRotation(ctx, token, jwtTkn, logCtx), nil
 	}
 
-	jwtTkn, err := argoCDClient.GenerateToken(project)
+	if dryRun {
+		return r.planIssue(ctx, token, logCtx, project, fmt.Sprintf("Create Secret %s holding the new token", namespaceName), "", ""), nil
+	}
+
+	jwtTkn, err := argoCDClient.GenerateToken(argoCtx, project)
 	if err != nil {
-		logCtx.Info(err.Error())
-		return ctrl.Result{}, nil
+		return r.argoCDFailed(ctx, token, logCtx, err)
 	}
 
 	//token.Status.TokenIssuedAts = append(token.Status.TokenIssuedAts, jwt.ReturnIAT(jwtTkn))
 	//fmt.Println(token.Status.TokenIssuedAts)
 
-	secret, err := r.createSecret(ctx, jwtTkn, logCtx, token)
+	secret, err := r.createSecret(ctx, jwtTkn, logCtx, *token)
+	if apierrors.IsAlreadyExists(err) {
+		// The Secret was created since it was read from the cache, e.g. for another of the Token's instances
+		secret = &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: namespaceName.Name, Namespace: namespaceName.Namespace}}
+		err = r.patchSecret(ctx, secret, jwtTkn, logCtx, *token)
+	}
 	if err != nil {
-		logCtx.Info(err.Error())
-		return ctrl.Result{}, nil
+		r.discardToken(argoCtx, argoCDClient, jwtTkn, logCtx)
+		return ctrl.Result{Requeue: true}, nil
 	}
 
 	secretMsg := fmt.Sprintf("Secret %s created!", secret.ObjectMeta.Name)
 	logCtx.Info(secretMsg)
+	r.rotated(ctx, token, rotateAt, logCtx)
+
+	return r.planRotation(ctx, token, jwtTkn, logCtx), nil
+}
+
+// swapToken rotates the token by minting the new token first and revoking the old one only after the Secret was
+// updated, so consumers always find a usable token. Failing to revoke the old token is only logged.
+func (r *TokenReconciler) swapToken(ctx context.Context, argoCtx context.Context, argoCDClient argocd.ArgoCDAPI, project argocd.AppProject,
+	tknSecret *corev1.Secret, token argoprojlabsv1.Token, oldClaims *jwt.Claims, logCtx logr.Logger) (string, error) {
+
+	newTkn, err := argoCDClient.GenerateToken(argoCtx, project)
+	if err != nil {
+		return "", err
+	}
+	err = r.patchSecret(ctx, tknSecret, newTkn, logCtx, token)
+	if err != nil {
+		r.discardToken(argoCtx, argoCDClient, newTkn, logCtx)
+		return "", err
+	}
+	err = argoCDClient.DeleteTokenIssuedAt(argoCtx, oldClaims.IssuedAt)
+	if err != nil {
+		logCtx.Info(err.Error())
+	}
+	return newTkn, nil
+}
+
+// discardToken revokes a token that was minted but couldn't be stored, so it doesn't stay valid unused in Argo CD.
+// Failing to revoke it is only logged.
+func (r *TokenReconciler) discardToken(argoCtx context.Context, argoCDClient argocd.ArgoCDAPI, jwtTkn string, logCtx logr.Logger) {
+	claims, err := jwt.ParseClaims(jwtTkn)
+	if err == nil {
+		err = argoCDClient.DeleteTokenIssuedAt(argoCtx, claims.IssuedAt)
+	}
+	if err != nil {
+		logCtx.Info(err.Error())
+	}
+}
+
+// rotationRequested returns the Token's rotate-at annotation if it asks for a rotation that wasn't handled yet
+func rotationRequested(token argoprojlabsv1.Token, logCtx logr.Logger) string {
+	rotateAt := token.Annotations[argoprojlabsv1.RotateAtAnnotation]
+	if rotateAt == "" || rotateAt == token.Status.LastRotateAt {
+		return ""
+	}
 
-	scheduleReconcile := ctrl.Result{RequeueAfter: time.Duration(jwt.TimeTillExpire(jwtTkn)) * time.Second}
-	return scheduleReconcile, nil
+	requested, err := time.Parse(time.RFC3339, rotateAt)
+	if err != nil {
+		logCtx.Info("Ignoring invalid "+argoprojlabsv1.RotateAtAnnotation+" annotation", "error", err.Error())
+		return ""
+	}
+	if last, err := time.Parse(time.RFC3339, token.Status.LastRotateAt); err == nil && !requested.After(last) {
+		return ""
+	}
+
+	return rotateAt
+}
+
+// rotated records in the Token's status that the rotation requested at rotateAt was handled
+func (r *TokenReconciler) rotated(ctx context.Context, token *argoprojlabsv1.Token, rotateAt string, logCtx logr.Logger) {
+	if rotateAt == "" {
+		return
+	}
+
+	token.Status.LastRotateAt = rotateAt
+	if ctx.Value(deferStatusKey{}) != nil {
+		return
+	}
+
+	// Losing the handled rotate-at would rotate the token once more, so a write that conflicts with a change to the
+	// Token is retried on top of it
+	name := types.NamespacedName{Name: token.Name, Namespace: token.Namespace}
+	status := token.Status
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		err := r.updateTokenStatus(ctx, token)
+		if apierrors.IsConflict(err) {
+			if err := r.getToken(ctx, name, token); err != nil {
+				return err
+			}
+			token.Status = status
+		}
+		return err
+	})
+	if err != nil {
+		logCtx.Info(err.Error())
+	}
+	r.Defaults.Apply(&token.Spec)
 }
 
-// SetupWithManager sets up secrets to be watched and gets auth tkn to login to argocd
+// suspended marks the Token as suspended. Nothing is rotated until it resumes, so no rotation is planned either.
+func (r *TokenReconciler) suspended(ctx context.Context, token *argoprojlabsv1.Token, logCtx logr.Logger) {
+	logCtx.Info("Token is suspended, skipping reconcile")
+
+	changed := token.Status.SetCondition(argoprojlabsv1.TokenCondition{
+		Type:    argoprojlabsv1.TokenConditionSuspended,
+		Status:  corev1.ConditionTrue,
+		Reason:  "Suspended",
+		Message: "Calls to Argo CD and changes to the Secret are paused by spec.suspend",
+	})
+	if token.Status.NextRotationAt != "" {
+		token.Status.NextRotationAt = ""
+		changed = true
+	}
+	if !changed {
+		return
+	}
+
+	r.updateStatus(ctx, token, logCtx)
+}
+
+// argoCDFailed logs a failed Argo CD call and remembers the Token if Argo CD rejected the controller credential
+func (r *TokenReconciler) argoCDFailed(ctx context.Context, token *argoprojlabsv1.Token, logCtx logr.Logger, err error) (ctrl.Result, error) {
+	if argocd.IsUnavailable(err) {
+		return r.argoCDUnavailable(ctx, token, logCtx, r.Breakers.OpenFor(token.Spec.ArgoCDEndpt))
+	}
+
+	logCtx.Info(err.Error())
+
+	if argocd.IsUnauthorized(err) {
+		r.authFailuresMu.Lock()
+		r.authFailures[types.NamespacedName{Name: token.Name, Namespace: token.Namespace}] = struct{}{}
+		r.authFailuresMu.Unlock()
+		// retried with backoff, in case the credential is replaced without the controller noticing
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// argoCDUnavailable marks the Token as waiting for Argo CD to recover and requeues it for when its circuit breaker closes
+func (r *TokenReconciler) argoCDUnavailable(ctx context.Context, token *argoprojlabsv1.Token, logCtx logr.Logger, openFor time.Duration) (ctrl.Result, error) {
+	logCtx.Info("Argo CD is unavailable, skipping reconcile", "retryAfter", openFor)
+
+	r.setCondition(ctx, token, logCtx, argoprojlabsv1.TokenCondition{
+		Type:    argoprojlabsv1.TokenConditionArgoCDUnavailable,
+		Status:  corev1.ConditionTrue,
+		Reason:  "CircuitOpen",
+		Message: fmt.Sprintf("Calls to %s keep failing and are paused", token.Spec.ArgoCDEndpt),
+	})
+
+	if openFor <= 0 {
+		openFor = time.Second
+	}
+	return ctrl.Result{RequeueAfter: openFor}, nil
+}
+
+// setCondition records a condition in the Token's status, skipping the write if nothing changed
+func (r *TokenReconciler) setCondition(ctx context.Context, token *argoprojlabsv1.Token, logCtx logr.Logger, condition argoprojlabsv1.TokenCondition) {
+	if !token.Status.SetCondition(condition) {
+		return
+	}
+
+	r.updateStatus(ctx, token, logCtx)
+}
+
+// RetryAuthFailures re-enqueues every Token whose last reconcile failed with an auth error.
+// It is meant to be called after the controller credential was reloaded. A Token stays listed until a reconcile of it
+// ran, so a Token whose retry fails as well is retried again on the next reload.
+func (r *TokenReconciler) RetryAuthFailures() {
+	r.authFailuresMu.Lock()
+	failed := make([]types.NamespacedName, 0, len(r.authFailures))
+	for name := range r.authFailures {
+		failed = append(failed, name)
+	}
+	r.authFailuresMu.Unlock()
+
+	if len(failed) == 0 {
+		return
+	}
+
+	r.Log.Info("Controller credential changed, retrying Tokens that failed to authenticate", "count", len(failed))
+
+	go func() {
+		for _, name := range failed {
+			r.retryAuth <- event.GenericEvent{
+				Meta:   &metav1.ObjectMeta{Name: name.Name, Namespace: name.Namespace},
+				Object: &argoprojlabsv2.Token{},
+			}
+		}
+	}()
+}
+
+// RenewSelfToken enqueues the Token holding the controller's own credential.
+// It is meant to be called after Argo CD rejected that credential, so it is replaced without waiting for it to expire.
+func (r *TokenReconciler) RenewSelfToken() {
+	if r.SelfToken.Name == "" {
+		return
+	}
+
+	r.Log.Info("Controller credential rejected, replacing it", "token", r.SelfToken.String())
+
+	go func() {
+		r.retryAuth <- event.GenericEvent{
+			Meta:   &metav1.ObjectMeta{Name: r.SelfToken.Name, Namespace: r.SelfToken.Namespace},
+			Object: &argoprojlabsv2.Token{},
+		}
+	}()
+}
+
+// planRotation records when the Token's token is rotated next in its status and requeues the Token for then
+func (r *TokenReconciler) planRotation(ctx context.Context, token *argoprojlabsv1.Token, jwtTkn string, logCtx logr.Logger) ctrl.Result {
+	claims, err := jwt.ParseClaims(jwtTkn)
+	if err != nil {
+		return ctrl.Result{}
+	}
+	next, err := r.nextRotation(*token, claims)
+	if err != nil {
+		// an invalid schedule doesn't hold back rotation, the token is rotated when it expires
+		logCtx.Info(err.Error())
+	}
+
+	nextRotationAt := ""
+	if !next.IsZero() {
+		nextRotationAt = next.UTC().Format(time.RFC3339)
+	}
+	if token.Status.NextRotationAt != nextRotationAt {
+		token.Status.NextRotationAt = nextRotationAt
+		r.updateStatus(ctx, token, logCtx)
+	}
+
+	if next.IsZero() {
+		return ctrl.Result{}
+	}
+	requeueAfter := next.Sub(r.Clock.Now())
+	if requeueAfter < 0 {
+		requeueAfter = 0
+	}
+	return ctrl.Result{RequeueAfter: requeueAfter}
+}
+
+// nextRotation returns when the token is rotated next: when it expires, in the last rotation window before that if
+// the Token has a rotation schedule, or at the renewal point of the controller's own credential. It returns the zero
+// time for tokens that never expire.
+func (r *TokenReconciler) nextRotation(token argoprojlabsv1.Token, claims *jwt.Claims) (time.Time, error) {
+	if !claims.Expires() {
+		return time.Time{}, nil
+	}
+	now := r.Clock.Now()
+	if r.isSelfToken(token) {
+		return now.Add(selfRenewAfter(claims, now)), nil
+	}
+
+	deadline := now.Add(r.expiry().Remaining(claims))
+	if token.Spec.RotationSchedule == nil {
+		return deadline, nil
+	}
+	s, err := schedule.Parse(*token.Spec.RotationSchedule)
+	if err != nil {
+		return deadline, err
+	}
+	if planned := s.Plan(now, deadline); !planned.IsZero() {
+		return planned, nil
+	}
+	// no window opens before the token expires, so it is rotated on expiry regardless
+	return deadline, nil
+}
+
+// expiry makes expiry decisions on the reconciler's clock
+func (r *TokenReconciler) expiry() jwt.Expiry {
+	return jwt.Expiry{Clock: r.Clock, Skew: r.ClockSkew, RenewPercent: r.Defaults.RenewPercent}
+}
+
+// signingKey loads the key Argo CD signs tokens with, or returns nil if signatures aren't verified
+func (r *TokenReconciler) signingKey(ctx context.Context) ([]byte, error) {
+	if r.SigningKey == nil {
+		return nil, nil
+	}
+	return r.SigningKey(ctx)
+}
+
+// verifyToken parses the token found in the Token's Secret, reporting why it can't be kept
+func (r *TokenReconciler) verifyToken(token argoprojlabsv1.Token, jwtTkn string, signingKey []byte) (*jwt.Claims, error) {
+	claims, err := jwt.ParseClaims(jwtTkn)
+	if err != nil {
+		return nil, fmt.Errorf("Secret does not hold a token issued by Argo CD: %v", err)
+	}
+	if !r.VerifyTokens && signingKey == nil {
+		return claims, nil
+	}
+	return claims, jwt.VerifyToken(jwtTkn, token.Spec.Project, token.Spec.Role, signingKey)
+}
+
+// isSelfToken reports whether the Token holds the controller's own Argo CD credential
+func (r *TokenReconciler) isSelfToken(token argoprojlabsv1.Token) bool {
+	return r.SelfToken.Name != "" && r.SelfToken.Name == token.Name && r.SelfToken.Namespace == token.Namespace
+}
+
+// selfTokenRejected reports whether Argo CD rejected jwtTkn as the controller's own credential
+func (r *TokenReconciler) selfTokenRejected(jwtTkn string) bool {
+	selfAuth, ok := r.Auth.(*argocd.SelfManagedAuth)
+	return ok && selfAuth.Rejected(jwtTkn)
+}
+
+// selfRenewAfter returns how long until the token has used up selfRenewPercent of its lifetime
+func selfRenewAfter(claims *jwt.Claims, now time.Time) time.Duration {
+	if !claims.Expires() {
+		return 0
+	}
+	renewAt := time.Unix(claims.IssuedAt, 0).Add(claims.Lifetime() * selfRenewPercent / 100)
+	renewAfter := renewAt.Sub(now)
+	if renewAfter < 0 {
+		return 0
+	}
+	return renewAfter
+}
+
+// SetupWithManager sets up secrets to be watched and falls back to the AUTH_TKN token to login to argocd
 func (r *TokenReconciler) SetupWithManager(mgr ctrl.Manager) error {
 
-	r.authTkn = os.Getenv("AUTH_TKN")
+	if r.Auth == nil {
+		r.Auth = argocd.NewStaticAuth(os.Getenv("AUTH_TKN"))
+	}
+	if r.ArgoCDOptions == (argocd.Options{}) {
+		r.ArgoCDOptions = argocd.DefaultOptions()
+	}
+	if r.Breakers == nil {
+		r.Breakers = argocd.NewCircuitBreakers(5, 30*time.Second)
+	}
+	if r.Clients == nil {
+		r.Clients = argocd.NewClientCache(r.Auth, r.ArgoCDOptions, r.Breakers, "", argocd.SecretCredentialsRef(r.Client))
+	}
+	if r.Clock == nil {
+		r.Clock = clock.RealClock{}
+	}
+	if r.Recorder == nil {
+		r.Recorder = mgr.GetEventRecorderFor("token-controller")
+	}
+	if r.Workloads == nil {
+		r.Workloads = mgr.GetAPIReader()
+	}
 
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&argoprojlabsv1.Token{}).
-		Watches(&source.Kind{Type: &corev1.Secret{}},
-			&handler.EnqueueRequestsFromMapFunc{
-				ToRequests: handler.ToRequestsFunc(func(a handler.MapObject) []reconcile.Request {
+	r.authFailures = make(map[types.NamespacedName]struct{})
+	r.retryAuth = make(chan event.GenericEvent)
 
-					ctx := context.Background()
-					var allTkns argoprojlabsv1.TokenList
-					tknMatches := make([]argoprojlabsv1.Token, 0)
+	if err := r.Shard.Validate(); err != nil {
+		return err
+	}
+
+	// The builder can't set MaxConcurrentReconciles yet, so the controller is set up by hand
+	c, err := controller.New("token", mgr, controller.Options{
+		Reconciler:              r,
+		MaxConcurrentReconciles: r.MaxConcurrentReconciles,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = c.Watch(&source.Kind{Type: &argoprojlabsv2.Token{}}, &handler.EnqueueRequestForObject{}, r.shardPredicate())
+	if err != nil {
+		return err
+	}
+	err = c.Watch(&source.Channel{Source: r.retryAuth}, &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+	if r.Projects != nil {
+		err = c.Watch(&source.Kind{Type: argocd.NewAppProjectObject()},
+			&handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.projectTokens)}, r.projectPredicate())
+		if err != nil {
+			return err
+		}
+	}
+	return c.Watch(&source.Kind{Type: &corev1.Secret{}},
+		&handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.secretTokens)})
+}
 
-					err := r.List(ctx, &allTkns)
-					if err != nil {
-						return []reconcile.Request{}
-					}
+// secretTokens maps a Secret to the Tokens of this shard that store their token in it. Only Tokens in the Secret's
+// own namespace match: a Token's Secret always lives next to it, and Secrets of the same name in other namespaces
+// belong to other Tokens.
+func (r *TokenReconciler) secretTokens(a handler.MapObject) []reconcile.Request {
+	ctx := context.Background()
 
-					for _, token := range allTkns.Items {
-						if a.Meta.GetName() == token.Spec.SecretRef.Name {
-							tknMatches = append(tknMatches, token)
-						}
-					}
+	tokens, err := r.listTokens(ctx, client.InNamespace(a.Meta.GetNamespace()))
+	if err != nil {
+		return []reconcile.Request{}
+	}
 
-					requests := make([]reconcile.Request, 0)
+	requests := make([]reconcile.Request, 0)
+	for _, token := range tokens {
+		if a.Meta.GetName() == token.Spec.SecretRef.Name && a.Meta.GetNamespace() == token.Namespace &&
+			r.Shard.Owns(token.UID) {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+				Name:      token.Name,
+				Namespace: token.Namespace,
+			}})
+		}
+	}
 
-					for _, token := range tknMatches {
-						namespaceName := types.NamespacedName{
-							Name:      token.Name,
-							Namespace: token.Namespace,
-						}
-						requests = append(requests, reconcile.Request{NamespacedName: namespaceName})
-					}
+	return requests
+}
 
-					return requests
-				}),
-			}).
-		Complete(r)
+// shardPredicate drops the events of Tokens that belong to other shards
+func (r *TokenReconciler) shardPredicate() predicate.Predicate {
+	return predicate.Funcs{
+		CreateFunc:  func(e event.CreateEvent) bool { return r.Shard.Owns(e.Meta.GetUID()) },
+		UpdateFunc:  func(e event.UpdateEvent) bool { return r.Shard.Owns(e.MetaNew.GetUID()) },
+		DeleteFunc:  func(e event.DeleteEvent) bool { return r.Shard.Owns(e.Meta.GetUID()) },
+		GenericFunc: func(e event.GenericEvent) bool { return r.Shard.Owns(e.Meta.GetUID()) },
+	}
 }
 
 // A helper function to create Secrets from strings
@@ -208,6 +813,11 @@ func (r *TokenReconciler) createSecret(ctx context.Context, tknStr string, logCt
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      token.Spec.SecretRef.Name,
 			Namespace: token.ObjectMeta.Namespace,
+			Labels:    r.Defaults.SecretLabels,
+			// the Secret is garbage collected together with its Token, the Argo CD token is left to expire
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(&token, argoprojlabsv2.GroupVersion.WithKind("Token")),
+			},
 		},
 		StringData: map[string]string{
 			token.Spec.SecretRef.Key: tknStr,
@@ -236,6 +846,7 @@ func (r *TokenReconciler) patchSecret(ctx context.Context, tknSecret *corev1.Sec
 		return err
 	}
 
+	r.restartRolloutTargets(ctx, token, tknStr, logCtx)
 	return nil
 }
 
@@ -0,0 +1,402 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/rand"
+
+	argoprojlabsv1 "github.com/argoproj-labs/argo-cd-tokens/api/v1"
+	"github.com/argoproj-labs/argo-cd-tokens/utils/argocd/fake"
+	"github.com/argoproj-labs/argo-cd-tokens/utils/jwt"
+)
+
+const (
+	timeout  = 10 * time.Second
+	interval = 250 * time.Millisecond
+)
+
+// newNamespace creates a namespace of its own for every spec
+func newNamespace() string {
+	namespace := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "tokens-" + rand.String(6)},
+	}
+	Expect(k8sClient.Create(context.Background(), &namespace)).To(Succeed())
+	return namespace.Name
+}
+
+func newToken(namespace string, role string, expiresIn int) *argoprojlabsv1.Token {
+	return &argoprojlabsv1.Token{
+		ObjectMeta: metav1.ObjectMeta{Name: "ci-token", Namespace: namespace},
+		Spec: argoprojlabsv1.TokenSpec{
+			Project:     "default",
+			Role:        role,
+			ArgoCDEndpt: argoCDServer.URL,
+			ExpiresIn:   &expiresIn,
+			SecretRef: argoprojlabsv1.SecretReference{
+				Name: "argocd-token",
+				Key:  "token",
+			},
+		},
+	}
+}
+
+// secretToken returns the token stored in the Token's Secret, or "" if there is no Secret
+func secretToken(token *argoprojlabsv1.Token) func() string {
+	return func() string {
+		var secret corev1.Secret
+		err := k8sClient.Get(context.Background(), types.NamespacedName{
+			Name:      token.Spec.SecretRef.Name,
+			Namespace: token.Namespace,
+		}, &secret)
+		if err != nil {
+			return ""
+		}
+		return string(secret.Data[token.Spec.SecretRef.Key])
+	}
+}
+
+// issuedAts returns the issued at times of the tokens the fake Argo CD holds for a role
+func issuedAts(role string) []int64 {
+	project, _ := argoCD.Project("default")
+	iats := make([]int64, 0)
+	for _, projectRole := range project.Spec.Roles {
+		if projectRole.Name == role {
+			for _, jwtToken := range projectRole.JWTTokens {
+				iats = append(iats, jwtToken.IssuedAt)
+			}
+		}
+	}
+	return iats
+}
+
+// issuedAt returns the iat of a token, or 0 if it can't be parsed
+func issuedAt(jwtTkn string) int64 {
+	claims, err := jwt.ParseClaims(jwtTkn)
+	if err != nil {
+		return 0
+	}
+	return claims.IssuedAt
+}
+
+var _ = Describe("Token controller", func() {
+	ctx := context.Background()
+
+	It("creates a Secret holding a token issued by Argo CD", func() {
+		token := newToken(newNamespace(), "ci", 3600)
+		Expect(k8sClient.Create(ctx, token)).To(Succeed())
+
+		Eventually(secretToken(token), timeout, interval).ShouldNot(BeEmpty())
+		claims, err := jwt.ParseClaims(secretToken(token)())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(claims.Expired(time.Now())).To(BeFalse())
+		Expect(issuedAts("ci")).To(ContainElement(claims.IssuedAt))
+	})
+
+	It("rotates an expired token and revokes the old one", func() {
+		token := newToken(newNamespace(), "ci", 2)
+		Expect(k8sClient.Create(ctx, token)).To(Succeed())
+
+		Eventually(secretToken(token), timeout, interval).ShouldNot(BeEmpty())
+		first, err := jwt.ParseClaims(secretToken(token)())
+		Expect(err).ToNot(HaveOccurred())
+
+		Eventually(func() int64 { return issuedAt(secretToken(token)()) }, timeout, interval).ShouldNot(Equal(first.IssuedAt))
+		Eventually(func() []int64 { return issuedAts("ci") }, timeout, interval).
+			ShouldNot(ContainElement(first.IssuedAt))
+	})
+
+	It("recreates a deleted Secret", func() {
+		token := newToken(newNamespace(), "ci", 3600)
+		Expect(k8sClient.Create(ctx, token)).To(Succeed())
+		Eventually(secretToken(token), timeout, interval).ShouldNot(BeEmpty())
+		first := secretToken(token)()
+
+		secret := corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: token.Spec.SecretRef.Name, Namespace: token.Namespace}}
+		Expect(k8sClient.Delete(ctx, &secret)).To(Succeed())
+
+		Eventually(secretToken(token), timeout, interval).ShouldNot(SatisfyAny(BeEmpty(), Equal(first)))
+	})
+
+	It("replaces a tampered token", func() {
+		token := newToken(newNamespace(), "ci", 3600)
+		Expect(k8sClient.Create(ctx, token)).To(Succeed())
+		Eventually(secretToken(token), timeout, interval).ShouldNot(BeEmpty())
+		iat := issuedAt(secretToken(token)())
+
+		var secret corev1.Secret
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: token.Spec.SecretRef.Name, Namespace: token.Namespace}, &secret)).To(Succeed())
+		secret.Data[token.Spec.SecretRef.Key] = []byte("tampered")
+		Expect(k8sClient.Update(ctx, &secret)).To(Succeed())
+
+		Eventually(func() int64 { return issuedAt(secretToken(token)()) }, timeout, interval).ShouldNot(BeZero())
+		// the overwritten token can't be identified from the Secret anymore, so it is left to expire
+		Expect(issuedAts("ci")).To(ContainElement(iat))
+	})
+
+	It("replaces a token revoked in Argo CD", func() {
+		token := newToken(newNamespace(), "ci", 3600)
+		Expect(k8sClient.Create(ctx, token)).To(Succeed())
+		Eventually(secretToken(token), timeout, interval).ShouldNot(BeEmpty())
+		first := secretToken(token)()
+
+		argoCDClient, err := argoCD.Client(ctx, *token)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(argoCDClient.DeleteToken(ctx, first)).To(Succeed())
+
+		// a change to an in-cluster AppProject would enqueue the Token, here it is touched instead
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: token.Name, Namespace: token.Namespace}, token)).To(Succeed())
+		token.Labels = map[string]string{"touched": "true"}
+		Expect(k8sClient.Update(ctx, token)).To(Succeed())
+
+		Eventually(secretToken(token), timeout, interval).ShouldNot(SatisfyAny(BeEmpty(), Equal(first)))
+		Expect(issuedAts("ci")).To(ContainElement(issuedAt(secretToken(token)())))
+	})
+
+	It("replaces a token issued for another role", func() {
+		token := newToken(newNamespace(), "ci", 3600)
+		Expect(k8sClient.Create(ctx, token)).To(Succeed())
+		Eventually(secretToken(token), timeout, interval).ShouldNot(BeEmpty())
+
+		otherRole := newToken(token.Namespace, "deploy", 3600)
+		otherClient, err := argoCD.Client(ctx, *otherRole)
+		Expect(err).ToNot(HaveOccurred())
+		project, err := otherClient.GetProject(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		otherTkn, err := otherClient.GenerateToken(ctx, project)
+		Expect(err).ToNot(HaveOccurred())
+
+		var secret corev1.Secret
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: token.Spec.SecretRef.Name, Namespace: token.Namespace}, &secret)).To(Succeed())
+		secret.Data[token.Spec.SecretRef.Key] = []byte(otherTkn)
+		Expect(k8sClient.Update(ctx, &secret)).To(Succeed())
+
+		Eventually(func() error {
+			return jwt.VerifyToken(secretToken(token)(), "default", "ci", argoCD.SigningKey)
+		}, timeout, interval).Should(Succeed())
+	})
+
+	It("rotates a token on request without leaving the Secret empty", func() {
+		token := newToken(newNamespace(), "ci", 3600)
+		Expect(k8sClient.Create(ctx, token)).To(Succeed())
+		Eventually(secretToken(token), timeout, interval).ShouldNot(BeEmpty())
+		first := issuedAt(secretToken(token)())
+
+		rotateAt := time.Now().UTC().Format(time.RFC3339)
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: token.Name, Namespace: token.Namespace}, token)).To(Succeed())
+		token.Annotations = map[string]string{argoprojlabsv1.RotateAtAnnotation: rotateAt}
+		Expect(k8sClient.Update(ctx, token)).To(Succeed())
+
+		Eventually(func() int64 { return issuedAt(secretToken(token)()) }, timeout, interval).ShouldNot(Equal(first))
+		Eventually(func() []int64 { return issuedAts("ci") }, timeout, interval).ShouldNot(ContainElement(first))
+		Eventually(func() string {
+			var rotated argoprojlabsv1.Token
+			_ = k8sClient.Get(ctx, types.NamespacedName{Name: token.Name, Namespace: token.Namespace}, &rotated)
+			return rotated.Status.LastRotateAt
+		}, timeout, interval).Should(Equal(rotateAt))
+
+		// a handled request doesn't rotate again
+		second := issuedAt(secretToken(token)())
+		Consistently(func() int64 { return issuedAt(secretToken(token)()) }, 2*time.Second, interval).Should(Equal(second))
+	})
+
+	It("leaves a suspended Token alone and catches up when it resumes", func() {
+		token := newToken(newNamespace(), "ci", 3600)
+		Expect(k8sClient.Create(ctx, token)).To(Succeed())
+		Eventually(secretToken(token), timeout, interval).ShouldNot(BeEmpty())
+		first := issuedAt(secretToken(token)())
+
+		name := types.NamespacedName{Name: token.Name, Namespace: token.Namespace}
+		suspendedStatus := func() corev1.ConditionStatus {
+			var current argoprojlabsv1.Token
+			_ = k8sClient.Get(ctx, name, &current)
+			if condition := current.Status.GetCondition(argoprojlabsv1.TokenConditionSuspended); condition != nil {
+				return condition.Status
+			}
+			return ""
+		}
+
+		Expect(k8sClient.Get(ctx, name, token)).To(Succeed())
+		token.Spec.Suspend = true
+		Expect(k8sClient.Update(ctx, token)).To(Succeed())
+		Eventually(suspendedStatus, timeout, interval).Should(Equal(corev1.ConditionTrue))
+
+		// neither a rotation request nor a deleted Secret is acted on while suspended
+		Expect(k8sClient.Get(ctx, name, token)).To(Succeed())
+		token.Annotations = map[string]string{argoprojlabsv1.RotateAtAnnotation: time.Now().UTC().Format(time.RFC3339)}
+		Expect(k8sClient.Update(ctx, token)).To(Succeed())
+		var secret corev1.Secret
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: token.Spec.SecretRef.Name, Namespace: token.Namespace}, &secret)).To(Succeed())
+		Expect(k8sClient.Delete(ctx, &secret)).To(Succeed())
+		Consistently(secretToken(token), 2*time.Second, interval).Should(BeEmpty())
+		Expect(issuedAts("ci")).To(ContainElement(first))
+
+		Expect(k8sClient.Get(ctx, name, token)).To(Succeed())
+		token.Spec.Suspend = false
+		Expect(k8sClient.Update(ctx, token)).To(Succeed())
+		Eventually(suspendedStatus, timeout, interval).Should(Equal(corev1.ConditionFalse))
+		Eventually(secretToken(token), timeout, interval).ShouldNot(BeEmpty())
+	})
+
+	It("only plans what it would do for a Token in dry-run mode", func() {
+		token := newToken(newNamespace(), "ci", 3600)
+		token.Spec.DryRun = true
+		Expect(k8sClient.Create(ctx, token)).To(Succeed())
+
+		name := types.NamespacedName{Name: token.Name, Namespace: token.Namespace}
+		plannedActions := func() []string {
+			var current argoprojlabsv1.Token
+			_ = k8sClient.Get(ctx, name, &current)
+			return current.Status.PlannedActions
+		}
+		Eventually(plannedActions, timeout, interval).Should(Equal([]string{
+			"Issue a token for role ci of project default",
+			"Create Secret " + token.Namespace + "/argocd-token holding the new token",
+		}))
+		Consistently(secretToken(token), 2*time.Second, interval).Should(BeEmpty())
+
+		Expect(k8sClient.Get(ctx, name, token)).To(Succeed())
+		token.Spec.DryRun = false
+		Expect(k8sClient.Update(ctx, token)).To(Succeed())
+		Eventually(secretToken(token), timeout, interval).ShouldNot(BeEmpty())
+		Eventually(plannedActions, timeout, interval).Should(BeEmpty())
+	})
+
+	It("restarts rollout targets after rotating a token", func() {
+		namespace := newNamespace()
+		labels := map[string]string{"app": "consumer"}
+		deployment := &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "consumer", Namespace: namespace},
+			Spec: appsv1.DeploymentSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: labels},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: labels},
+					Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "consumer", Image: "consumer"}}},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, deployment)).To(Succeed())
+
+		token := newToken(namespace, "ci", 3600)
+		token.Spec.RolloutTargets = []argoprojlabsv1.RolloutTarget{{Kind: "Deployment", Selector: &metav1.LabelSelector{MatchLabels: labels}}}
+		Expect(k8sClient.Create(ctx, token)).To(Succeed())
+		Eventually(secretToken(token), timeout, interval).ShouldNot(BeEmpty())
+
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: token.Name, Namespace: token.Namespace}, token)).To(Succeed())
+		token.Annotations = map[string]string{argoprojlabsv1.RotateAtAnnotation: time.Now().UTC().Format(time.RFC3339)}
+		Expect(k8sClient.Update(ctx, token)).To(Succeed())
+
+		Eventually(func() bool {
+			var restarted appsv1.Deployment
+			_ = k8sClient.Get(ctx, types.NamespacedName{Name: deployment.Name, Namespace: namespace}, &restarted)
+			return restarted.Spec.Template.Annotations[argoprojlabsv1.TokenHashAnnotation] == tokenHash(secretToken(token)())
+		}, timeout, interval).Should(BeTrue())
+	})
+
+	It("issues a token from each Argo CD instance into its own key", func() {
+		otherArgoCD := fake.NewArgoCD(fake.NewProject("default", "ci"))
+		otherServer := fake.NewServer(otherArgoCD, controllerAuthTkn)
+		defer otherServer.Close()
+
+		token := newToken(newNamespace(), "ci", 3600)
+		token.Spec.Instances = []argoprojlabsv1.ArgoCDInstance{
+			{Name: "eu", ArgoCDEndpt: argoCDServer.URL},
+			{Name: "us", ArgoCDEndpt: otherServer.URL, Key: "us-token"},
+		}
+		Expect(k8sClient.Create(ctx, token)).To(Succeed())
+
+		keyToken := func(key string) func() string {
+			return func() string {
+				var secret corev1.Secret
+				_ = k8sClient.Get(ctx, types.NamespacedName{Name: token.Spec.SecretRef.Name, Namespace: token.Namespace}, &secret)
+				return string(secret.Data[key])
+			}
+		}
+		Eventually(keyToken("token-eu"), timeout, interval).ShouldNot(BeEmpty())
+		Eventually(keyToken("us-token"), timeout, interval).ShouldNot(BeEmpty())
+		Expect(issuedAts("ci")).To(ContainElement(issuedAt(keyToken("token-eu")())))
+
+		usProject, _ := otherArgoCD.Project("default")
+		Expect(usProject.Spec.Roles[0].JWTTokens).To(HaveLen(1))
+		Expect(usProject.Spec.Roles[0].JWTTokens[0].IssuedAt).To(Equal(issuedAt(keyToken("us-token")())))
+
+		Eventually(func() []string {
+			var current argoprojlabsv1.Token
+			_ = k8sClient.Get(ctx, types.NamespacedName{Name: token.Name, Namespace: token.Namespace}, &current)
+			names := make([]string, 0)
+			for _, status := range current.Status.Instances {
+				if status.NextRotationAt != "" {
+					names = append(names, status.Name)
+				}
+			}
+			return names
+		}, timeout, interval).Should(Equal([]string{"eu", "us"}))
+	})
+
+	It("creates no Secret when the role is missing from the project", func() {
+		token := newToken(newNamespace(), "missing", 3600)
+		Expect(k8sClient.Create(ctx, token)).To(Succeed())
+
+		Consistently(secretToken(token), 2*time.Second, interval).Should(BeEmpty())
+	})
+
+	It("leaves the Secret to be garbage collected and the Argo CD token valid when its Token is deleted", func() {
+		token := newToken(newNamespace(), "ci", 3600)
+		Expect(k8sClient.Create(ctx, token)).To(Succeed())
+		Eventually(secretToken(token), timeout, interval).ShouldNot(BeEmpty())
+		iat := issuedAt(secretToken(token)())
+
+		var secret corev1.Secret
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: token.Spec.SecretRef.Name, Namespace: token.Namespace}, &secret)).To(Succeed())
+		Expect(secret.OwnerReferences).To(HaveLen(1))
+		Expect(secret.OwnerReferences[0].UID).To(Equal(token.UID))
+
+		// envtest runs no garbage collector, so the Secret is deleted by hand
+		Expect(k8sClient.Delete(ctx, token)).To(Succeed())
+		Expect(k8sClient.Delete(ctx, &secret)).To(Succeed())
+		Consistently(secretToken(token), 2*time.Second, interval).Should(BeEmpty())
+
+		// the token is not revoked, it stays valid in Argo CD until it expires
+		Expect(issuedAts("ci")).To(ContainElement(iat))
+	})
+
+	It("keeps Secrets of the same name in different namespaces apart", func() {
+		first := newToken(newNamespace(), "ci", 3600)
+		second := newToken(newNamespace(), "ci", 3600)
+		Expect(k8sClient.Create(ctx, first)).To(Succeed())
+		Expect(k8sClient.Create(ctx, second)).To(Succeed())
+		Eventually(secretToken(first), timeout, interval).ShouldNot(BeEmpty())
+		Eventually(secretToken(second), timeout, interval).ShouldNot(BeEmpty())
+		firstTkn := secretToken(first)()
+		secondTkn := secretToken(second)()
+		Expect(firstTkn).ToNot(Equal(secondTkn))
+
+		secret := corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: first.Spec.SecretRef.Name, Namespace: first.Namespace}}
+		Expect(k8sClient.Delete(ctx, &secret)).To(Succeed())
+
+		Eventually(secretToken(first), timeout, interval).ShouldNot(SatisfyAny(BeEmpty(), Equal(firstTkn)))
+		Consistently(secretToken(second), 2*time.Second, interval).Should(Equal(secondTkn))
+	})
+})
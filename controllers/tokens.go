@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	argoprojlabsv1 "github.com/argoproj-labs/argo-cd-tokens/api/v1"
+	argoprojlabsv2 "github.com/argoproj-labs/argo-cd-tokens/api/v2"
+)
+
+// Tokens are read, watched and written in v2, the version the API server stores them in, so the controller never
+// waits on the conversion webhook. They are reconciled in the v1 form the reconciler is written against, which v2
+// converts to and from without loss.
+
+// getToken reads the Token into token
+func (r *TokenReconciler) getToken(ctx context.Context, name types.NamespacedName, token *argoprojlabsv1.Token) error {
+	var stored argoprojlabsv2.Token
+	if err := r.Get(ctx, name, &stored); err != nil {
+		return err
+	}
+	return token.ConvertFrom(&stored)
+}
+
+// listTokens lists the Tokens matching opts
+func (r *TokenReconciler) listTokens(ctx context.Context, opts ...client.ListOptionFunc) ([]argoprojlabsv1.Token, error) {
+	var stored argoprojlabsv2.TokenList
+	if err := r.List(ctx, &stored, opts...); err != nil {
+		return nil, err
+	}
+
+	tokens := make([]argoprojlabsv1.Token, len(stored.Items))
+	for i := range stored.Items {
+		if err := tokens[i].ConvertFrom(&stored.Items[i]); err != nil {
+			return nil, err
+		}
+	}
+	return tokens, nil
+}
+
+// updateTokenStatus writes the Token's status, leaving token as stored
+func (r *TokenReconciler) updateTokenStatus(ctx context.Context, token *argoprojlabsv1.Token) error {
+	stored, err := hubToken(token)
+	if err != nil {
+		return err
+	}
+	if err := r.Status().Update(ctx, stored); err != nil {
+		return err
+	}
+	return token.ConvertFrom(stored)
+}
+
+// hubToken returns the Token in the version it is stored in
+func hubToken(token *argoprojlabsv1.Token) (*argoprojlabsv2.Token, error) {
+	var stored argoprojlabsv2.Token
+	err := token.ConvertTo(&stored)
+	return &stored, err
+}
@@ -1,6 +1,6 @@
 module github.com/argoproj-labs/argo-cd-tokens
 
-go 1.12
+go 1.13
 
 require (
 	github.com/Masterminds/semver v1.4.2
@@ -25,6 +25,7 @@ require (
 	github.com/onsi/gomega v1.5.0
 	github.com/patrickmn/go-cache v2.1.0+incompatible
 	github.com/pquerna/cachecontrol v0.0.0-20180517163645-1555304b9b35 // indirect
+	github.com/robfig/cron/v3 v3.0.1
 	github.com/sirupsen/logrus v1.4.2
 	github.com/spf13/cobra v0.0.3
 	github.com/stretchr/testify v1.3.0
@@ -32,6 +33,7 @@ require (
 	golang.org/x/crypto v0.0.0-20190611184440-5c40567a22f8
 	golang.org/x/net v0.0.0-20190613194153-d28f0bde5980
 	golang.org/x/oauth2 v0.0.0-20180821212333-d2e6202438be
+	golang.org/x/time v0.0.0-20180412165947-fbb02b2291d2
 	google.golang.org/genproto v0.0.0-20180817151627-c66870c02cf8
 	google.golang.org/grpc v1.22.0
 	gopkg.in/square/go-jose.v2 v2.3.1 // indirect
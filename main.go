@@ -16,15 +16,29 @@ limitations under the License.
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"flag"
+	"fmt"
+	"io/ioutil"
 	"os"
+	"strings"
+	"time"
 
 	argoprojlabsv1 "github.com/argoproj-labs/argo-cd-tokens/api/v1"
+	argoprojlabsv2 "github.com/argoproj-labs/argo-cd-tokens/api/v2"
 	"github.com/argoproj-labs/argo-cd-tokens/controllers"
+	"github.com/argoproj-labs/argo-cd-tokens/utils/argocd"
+	"github.com/argoproj-labs/argo-cd-tokens/utils/jwt"
+	"github.com/argoproj-labs/argo-cd-tokens/utils/shard"
+	"github.com/ghodss/yaml"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	// +kubebuilder:scaffold:imports
 )
@@ -37,38 +51,268 @@ var (
 func init() {
 
 	argoprojlabsv1.AddToScheme(scheme)
+	argoprojlabsv2.AddToScheme(scheme)
 	corev1.AddToScheme(scheme)
+	appsv1.AddToScheme(scheme)
 	// +kubebuilder:scaffold:scheme
 }
 
 func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
+	var authSecret string
+	var selfToken string
+	var authTokenFile string
+	var authTokenFilePoll time.Duration
+	argoCDOptions := argocd.DefaultOptions()
+	var breakerThreshold int
+	var breakerCooldown time.Duration
+	var argoCDCAFile string
+	var verifyTokens bool
+	var clockSkew time.Duration
+	var signingKeySecret string
+	var dryRun bool
+	var watchNamespaces string
+	var tokenShard shard.Shard
+	var maxConcurrentReconciles int
+	var argoCDNamespace string
+	var argoCDInClusterEndpoint string
+	var enableConversionWebhook bool
+	var configFile string
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8080", "The address the metric endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false,
 		"Enable leader election for controller manager. Enabling this will ensure there is only one active controller manager.")
+	flag.StringVar(&authSecret, "auth-secret", "",
+		"The namespace/name of a Secret holding the username and password to log in to Argo CD with. Defaults to the AUTH_TKN token.")
+	flag.StringVar(&authTokenFile, "auth-token-file", "",
+		"A file, e.g. a mounted Secret, holding the token to log in to Argo CD with. It is reloaded when it changes.")
+	flag.DurationVar(&authTokenFilePoll, "auth-token-file-poll-interval", 10*time.Second,
+		"How often --auth-token-file is checked for a new token.")
+	flag.DurationVar(&argoCDOptions.RequestTimeout, "argocd-request-timeout", argoCDOptions.RequestTimeout,
+		"The timeout of a single call to Argo CD.")
+	flag.DurationVar(&argoCDOptions.Timeout, "argocd-timeout", argoCDOptions.Timeout,
+		"The timeout of all calls to Argo CD made while reconciling one Token.")
+	flag.IntVar(&argoCDOptions.MaxRetries, "argocd-max-retries", argoCDOptions.MaxRetries,
+		"How often an idempotent call to Argo CD failing with a connection error or 5xx is retried.")
+	flag.Float64Var(&argoCDOptions.QPS, "argocd-qps", argoCDOptions.QPS,
+		"How many calls per second are made to each Argo CD endpoint, across all Tokens. 0 does not limit them.")
+	flag.IntVar(&argoCDOptions.Burst, "argocd-burst", argoCDOptions.Burst,
+		"How many calls to an Argo CD endpoint may be made at once before --argocd-qps applies.")
+	flag.DurationVar(&argoCDOptions.ProjectCacheTTL, "argocd-project-cache-ttl", argoCDOptions.ProjectCacheTTL,
+		"How long a project fetched from Argo CD is reused for other Tokens of the same project.")
+	flag.IntVar(&breakerThreshold, "argocd-breaker-threshold", 5,
+		"The number of consecutive failed calls to an Argo CD endpoint after which calls to it are paused.")
+	flag.DurationVar(&breakerCooldown, "argocd-breaker-cooldown", 30*time.Second,
+		"How long calls to a failing Argo CD endpoint are paused before it is probed again.")
+	flag.StringVar(&argoCDCAFile, "argocd-ca-file", "",
+		"A PEM bundle of the CAs Argo CD's certificate is verified against. The certificate is not verified if unset.")
+	flag.BoolVar(&verifyTokens, "verify-tokens", false,
+		"Replace tokens in Secrets that were not issued by Argo CD for the Token's project and role.")
+	flag.StringVar(&signingKeySecret, "argocd-signing-key-secret", "",
+		"The namespace/name of a Secret, usually argocd-secret, holding the server.secretkey Argo CD signs tokens with. "+
+			"Setting it makes --verify-tokens verify token signatures too.")
+	flag.DurationVar(&clockSkew, "clock-skew", 10*time.Second,
+		"How far the controller's clock may be off from Argo CD's. Tokens are rotated this much before they expire, "+
+			"but by at most 10% of their lifetime.")
+	flag.StringVar(&selfToken, "self-token", "",
+		"The namespace/name of a Token whose Secret holds the controller's own Argo CD credential. "+
+			"The --auth-secret, --auth-token-file or AUTH_TKN credential is then only used to bootstrap and recover it.")
+	flag.BoolVar(&dryRun, "dry-run", false,
+		"Only record in each Token's status and Events what the controller would do, without issuing or revoking "+
+			"tokens or writing Secrets.")
+	flag.StringVar(&watchNamespaces, "watch-namespaces", "",
+		"A comma separated list of the namespaces whose Tokens are reconciled. All namespaces are watched if unset, "+
+			"which needs a ClusterRole. Watching only some of them needs a Role in each.")
+	flag.IntVar(&tokenShard.Count, "shards", 0,
+		"Split Tokens by a consistent hash of their UID across this many controller replicas, all reconciling at once. "+
+			"Each replica runs with its own --shard-id and, with leader election, elects a leader of its own.")
+	flag.IntVar(&tokenShard.ID, "shard-id", 0, "Which of the --shards this replica reconciles, counting from 0.")
+	flag.StringVar(&argoCDNamespace, "argocd-namespace", "",
+		"The namespace of an Argo CD running in the same cluster. Projects are then read from its AppProjects, "+
+			"which are watched to reconcile Tokens when their role or its tokens change. It has to be one of "+
+			"--watch-namespaces if those are set.")
+	flag.StringVar(&argoCDInClusterEndpoint, "argocd-in-cluster-endpoint", "",
+		"The endpoint of the Argo CD in --argocd-namespace, required with it. Only the Tokens and instances of this "+
+			"endpoint read their project from the cluster, all others still ask their Argo CD.")
+	flag.BoolVar(&enableConversionWebhook, "enable-conversion-webhook", false,
+		"Serve the webhook converting Tokens between API versions. Its certificate is read from "+
+			"/tmp/k8s-webhook-server/serving-certs.")
+	flag.IntVar(&maxConcurrentReconciles, "max-concurrent-reconciles", 1, "How many Tokens are reconciled at once.")
+	flag.StringVar(&configFile, "config", "",
+		"A TokenControllerConfig file holding defaults for the spec of every Token and for the connections to Argo CD. "+
+			"Flags given on the command line take precedence.")
 	flag.Parse()
 
 	ctrl.SetLogger(zap.Logger(true))
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	var config argoprojlabsv1.TokenControllerConfig
+	if configFile != "" {
+		var err error
+		config, err = loadConfig(configFile)
+		if err != nil {
+			setupLog.Error(err, "unable to load --config")
+			os.Exit(1)
+		}
+		setFlags := make(map[string]bool)
+		flag.Visit(func(f *flag.Flag) { setFlags[f.Name] = true })
+		if config.ArgoCD.CAFile != "" && !setFlags["argocd-ca-file"] {
+			argoCDCAFile = config.ArgoCD.CAFile
+		}
+		if config.ArgoCD.QPS != nil && !setFlags["argocd-qps"] {
+			argoCDOptions.QPS = *config.ArgoCD.QPS
+		}
+		if config.ArgoCD.Burst != nil && !setFlags["argocd-burst"] {
+			argoCDOptions.Burst = *config.ArgoCD.Burst
+		}
+	}
+
+	mgrOptions := ctrl.Options{
 		Scheme:             scheme,
 		MetricsBindAddress: metricsAddr,
 		LeaderElection:     enableLeaderElection,
-	})
+	}
+	namespaces := parseNamespaces(watchNamespaces)
+	switch len(namespaces) {
+	case 0:
+	case 1:
+		mgrOptions.Namespace = namespaces[0]
+	default:
+		mgrOptions.NewCache = cache.MultiNamespacedCacheBuilder(namespaces)
+	}
+	if len(namespaces) > 0 {
+		setupLog.Info("watching Tokens in some namespaces only", "namespaces", namespaces)
+	}
+	if err := tokenShard.Validate(); err != nil {
+		setupLog.Error(err, "invalid --shard-id")
+		os.Exit(1)
+	}
+	if tokenShard.Count > 1 {
+		// replicas of different shards must not compete for one leader lock
+		mgrOptions.LeaderElectionID = fmt.Sprintf("argo-cd-tokens-shard-%d", tokenShard.ID)
+		setupLog.Info("reconciling a shard of the Tokens", "shard", tokenShard.ID, "shards", tokenShard.Count)
+	}
+
+	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), mgrOptions)
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
+	if enableConversionWebhook {
+		// The manager's webhook server serves conversion for every Token version on /convert
+		mgr.GetWebhookServer()
+	}
+
+	if authSecret != "" && authTokenFile != "" {
+		setupLog.Error(fmt.Errorf("--auth-secret and --auth-token-file are mutually exclusive"), "invalid flags")
+		os.Exit(1)
+	}
+
+	var auth argocd.AuthProvider
+	var fileAuth *argocd.FileAuth
+	if authTokenFile != "" {
+		if authTokenFilePoll <= 0 {
+			setupLog.Error(fmt.Errorf("must be positive, got %s", authTokenFilePoll), "invalid --auth-token-file-poll-interval")
+			os.Exit(1)
+		}
+		fileAuth, err = argocd.NewFileAuth(authTokenFile, authTokenFilePoll)
+		if err != nil {
+			setupLog.Error(err, "unable to read --auth-token-file")
+			os.Exit(1)
+		}
+		if err = mgr.Add(fileAuth); err != nil {
+			setupLog.Error(err, "unable to watch --auth-token-file")
+			os.Exit(1)
+		}
+		auth = fileAuth
+	}
+	if authSecret != "" {
+		secretName, err := parseNamespacedName(authSecret)
+		if err != nil {
+			setupLog.Error(err, "invalid --auth-secret")
+			os.Exit(1)
+		}
+		auth = argocd.NewSessionAuth(argocd.SecretCredentials(mgr.GetAPIReader(), secretName), argoCDOptions, argoCDCAFile)
+	}
+
+	var selfTokenName types.NamespacedName
+	var selfAuth *argocd.SelfManagedAuth
+	if selfToken != "" {
+		selfTokenName, err = parseNamespacedName(selfToken)
+		if err != nil {
+			setupLog.Error(err, "invalid --self-token")
+			os.Exit(1)
+		}
+		bootstrap := auth
+		if bootstrap == nil {
+			bootstrap = argocd.NewStaticAuth(os.Getenv("AUTH_TKN"))
+		}
+		if len(namespaces) > 0 && !containsString(namespaces, selfTokenName.Namespace) {
+			setupLog.Error(fmt.Errorf("namespace %s is not watched", selfTokenName.Namespace), "invalid --self-token")
+			os.Exit(1)
+		}
+		// The credential is read on every call to Argo CD, so it is read through the cache
+		selfAuth = argocd.NewSelfManagedAuth(argocd.SelfTokenSource(mgr.GetClient(), selfTokenName, config.TokenDefaults), bootstrap)
+		// the credential is given up as expired when the controller replaces it
+		selfAuth.Expiry = jwt.Expiry{Skew: clockSkew}
+		auth = selfAuth
+	}
+
+	if auth == nil {
+		auth = argocd.NewStaticAuth(os.Getenv("AUTH_TKN"))
+	}
+	breakers := argocd.NewCircuitBreakers(breakerThreshold, breakerCooldown)
+
+	var signingKey argocd.SigningKeyFunc
+	if signingKeySecret != "" {
+		secretName, err := parseNamespacedName(signingKeySecret)
+		if err != nil {
+			setupLog.Error(err, "invalid --argocd-signing-key-secret")
+			os.Exit(1)
+		}
+		signingKey = argocd.SecretSigningKey(mgr.GetAPIReader(), secretName)
+		verifyTokens = true
+	}
+
+	reconciler := &controllers.TokenReconciler{
+		Client:        mgr.GetClient(),
+		Log:           ctrl.Log.WithName("controllers").WithName("Token"),
+		Auth:          auth,
+		ArgoCDOptions: argoCDOptions,
+		Breakers:      breakers,
+		Clients:       argocd.NewClientCache(auth, argoCDOptions, breakers, argoCDCAFile, argocd.SecretCredentialsRef(mgr.GetClient())),
+		SelfToken:     selfTokenName,
+		VerifyTokens:  verifyTokens,
+		SigningKey:    signingKey,
+		ClockSkew:     clockSkew,
+		DryRun:        dryRun,
+		Shard:         tokenShard,
+		Defaults:      config.TokenDefaults,
 
-	if err = (&controllers.TokenReconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("Token"),
+		MaxConcurrentReconciles: maxConcurrentReconciles,
 		//Scheme: mgr.GetScheme(),
-	}).SetupWithManager(mgr); err != nil {
+	}
+	if argoCDNamespace != "" {
+		if argoCDInClusterEndpoint == "" {
+			setupLog.Error(fmt.Errorf("--argocd-in-cluster-endpoint is required"), "invalid --argocd-namespace")
+			os.Exit(1)
+		}
+		if len(namespaces) > 0 && !containsString(namespaces, argoCDNamespace) {
+			// the AppProjects are read through the cache, which only covers the watched namespaces
+			setupLog.Error(fmt.Errorf("namespace %s is not watched", argoCDNamespace), "invalid --argocd-namespace")
+			os.Exit(1)
+		}
+		reconciler.Projects = argocd.NewClusterProjects(mgr.GetCache(), argoCDNamespace, argoCDInClusterEndpoint)
+	}
+	if err = reconciler.SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Token")
 		os.Exit(1)
 	}
+	if fileAuth != nil {
+		fileAuth.OnChange(reconciler.RetryAuthFailures)
+	}
+	if selfAuth != nil {
+		selfAuth.OnReject(reconciler.RenewSelfToken)
+	}
 	// +kubebuilder:scaffold:builder
 
 	setupLog.Info("starting manager")
@@ -77,3 +321,59 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// parseNamespacedName parses a namespace/name flag value
+func parseNamespacedName(value string) (types.NamespacedName, error) {
+	parts := strings.SplitN(value, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return types.NamespacedName{}, fmt.Errorf("expected namespace/name, got %q", value)
+	}
+	return types.NamespacedName{Namespace: parts[0], Name: parts[1]}, nil
+}
+
+// loadConfig reads a TokenControllerConfig file, rejecting fields it doesn't know
+func loadConfig(path string) (argoprojlabsv1.TokenControllerConfig, error) {
+	var config argoprojlabsv1.TokenControllerConfig
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+	data, err = yaml.YAMLToJSON(data)
+	if err != nil {
+		return config, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(&config); err != nil {
+		return config, err
+	}
+	if config.APIVersion != argoprojlabsv1.GroupVersion.String() || config.Kind != "TokenControllerConfig" {
+		return config, fmt.Errorf("expected a %s TokenControllerConfig, got %s %s",
+			argoprojlabsv1.GroupVersion, config.APIVersion, config.Kind)
+	}
+	if renewPercent := config.TokenDefaults.RenewPercent; renewPercent < 0 || renewPercent > 100 {
+		return config, fmt.Errorf("tokenDefaults.renewPercent must be between 0 and 100, got %d", renewPercent)
+	}
+	return config, nil
+}
+
+// parseNamespaces parses the comma separated --watch-namespaces flag value
+func parseNamespaces(value string) []string {
+	namespaces := make([]string, 0)
+	for _, namespace := range strings.Split(value, ",") {
+		if namespace = strings.TrimSpace(namespace); namespace != "" {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	return namespaces
+}
+
+// containsString reports whether value is one of values
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
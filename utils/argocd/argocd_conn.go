@@ -1,15 +1,16 @@
 package argocd
 
 import (
-	"bytes"
+	"context"
 	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 
-	"encoding/json"
-	"io/ioutil"
 	"net/http"
+	"time"
 
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"github.com/argoproj/argo-cd/pkg/apis/application/v1alpha1"
 
 	argoprojlabsv1 "github.com/argoproj-labs/argo-cd-tokens/api/v1"
 	jwt "github.com/argoproj-labs/argo-cd-tokens/utils/jwt"
@@ -27,200 +28,311 @@ type Token struct {
 	Token string
 }
 
-// AppProject provides a logical grouping of applications, providing controls for:
-// * where the apps may deploy to (cluster whitelist)
-// * what may be deployed (repository whitelist, resource whitelist/blacklist)
-// * who can access these applications (roles, OIDC group claims bindings)
-// * and what they can do (RBAC policies)
-// * automation access to these roles (JWT tokens)
-// +genclient
-// +genclient:noStatus
-// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
-// +kubebuilder:resource:path=appprojects,shortName=appproj;appprojs
-type AppProject struct {
-	metav1.TypeMeta   `json:",inline"`
-	metav1.ObjectMeta `json:"metadata" protobuf:"bytes,1,opt,name=metadata"`
-	Spec              AppProjectSpec `json:"spec" protobuf:"bytes,2,opt,name=spec"`
-}
-
-// AppProjectSpec is the specification of an AppProject
-type AppProjectSpec struct {
-	// SourceRepos contains list of git repository URLs which can be used for deployment
-	SourceRepos []string `json:"sourceRepos,omitempty" protobuf:"bytes,1,name=sourceRepos"`
-	// Destinations contains list of destinations available for deployment
-	Destinations []ApplicationDestination `json:"destinations,omitempty" protobuf:"bytes,2,name=destination"`
-	// Description contains optional project description
-	Description string `json:"description,omitempty" protobuf:"bytes,3,opt,name=description"`
-	// Roles are user defined RBAC roles associated with this project
-	Roles []ProjectRole `json:"roles,omitempty" protobuf:"bytes,4,rep,name=roles"`
-	// ClusterResourceWhitelist contains list of whitelisted cluster level resources
-	ClusterResourceWhitelist []metav1.GroupKind `json:"clusterResourceWhitelist,omitempty" protobuf:"bytes,5,opt,name=clusterResourceWhitelist"`
-	// NamespaceResourceBlacklist contains list of blacklisted namespace level resources
-	NamespaceResourceBlacklist []metav1.GroupKind `json:"namespaceResourceBlacklist,omitempty" protobuf:"bytes,6,opt,name=namespaceResourceBlacklist"`
-}
-
-// ApplicationDestination contains deployment destination information
-type ApplicationDestination struct {
-	// Server overrides the environment server value in the ksonnet app.yaml
-	Server string `json:"server,omitempty" protobuf:"bytes,1,opt,name=server"`
-	// Namespace overrides the environment namespace value in the ksonnet app.yaml
-	Namespace string `json:"namespace,omitempty" protobuf:"bytes,2,opt,name=namespace"`
-}
-
-// ProjectRole represents a role that has access to a project
-type ProjectRole struct {
-	// Name is a name for this role
-	Name string `json:"name" protobuf:"bytes,1,opt,name=name"`
-	// Description is a description of the role
-	Description string `json:"description,omitempty" protobuf:"bytes,2,opt,name=description"`
-	// Policies Stores a list of casbin formated strings that define access policies for the role in the project
-	Policies []string `json:"policies,omitempty" protobuf:"bytes,3,rep,name=policies"`
-	// JWTTokens are a list of generated JWT tokens bound to this role
-	JWTTokens []JWTToken `json:"jwtTokens,omitempty" protobuf:"bytes,4,rep,name=jwtTokens"`
-	// Groups are a list of OIDC group claims bound to this role
-	Groups []string `json:"groups,omitempty" protobuf:"bytes,5,rep,name=groups"`
-}
-
-// JWTToken holds the issuedAt and expiresAt values of a token
-type JWTToken struct {
-	IssuedAt  int64 `json:"iat" protobuf:"int64,1,opt,name=iat"`
-	ExpiresAt int64 `json:"exp,omitempty" protobuf:"int64,2,opt,name=exp"`
-}
-
-// Client holds our client, the cookie used to login and a token object
-type Client struct {
-	client      http.Client
-	loginCookie http.Cookie
-	token       argoprojlabsv1.Token
+// ProjectUpdateRequest used for the payload of project updates
+type ProjectUpdateRequest struct {
+	Project AppProject `json:"project"`
 }
 
-// NewArgoCDClient constructs a Client object
-func NewArgoCDClient(authTkn string, token argoprojlabsv1.Token) Client {
+// The project types are the ones Argo CD generates for its API, so gRPC messages decode straight into them
+type (
+	// AppProject is an Argo CD project
+	AppProject = v1alpha1.AppProject
+	// AppProjectSpec is the specification of an AppProject
+	AppProjectSpec = v1alpha1.AppProjectSpec
+	// ApplicationDestination contains deployment destination information
+	ApplicationDestination = v1alpha1.ApplicationDestination
+	// ProjectRole represents a role that has access to a project
+	ProjectRole = v1alpha1.ProjectRole
+	// JWTToken holds the issuedAt and expiresAt values of a token
+	JWTToken = v1alpha1.JWTToken
+)
 
-	transCfg := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-	}
+// ErrUnauthorized is returned when Argo CD rejects the token even after logging in again
+var ErrUnauthorized = errors.New("Argo CD rejected the auth token")
+
+// IsUnauthorized reports whether a Client call failed because Argo CD rejected the auth token
+func IsUnauthorized(err error) bool {
+	return err == ErrUnauthorized
+}
 
-	loginCookie := http.Cookie{
-		Name:     "argocd.token",
-		Value:    authTkn,
-		Path:     "/",
-		MaxAge:   60 * 60,
-		HttpOnly: true,
+// Options configures the connection to Argo CD
+type Options struct {
+	// RequestTimeout bounds a single call to Argo CD, including reading the response
+	RequestTimeout time.Duration
+	// Timeout bounds all calls made to Argo CD while reconciling one Token
+	Timeout time.Duration
+	// MaxRetries is how often an idempotent call failing with a connection error or 5xx is retried
+	MaxRetries int
+	// RetryBaseDelay is the backoff before the first retry, it doubles with every further retry
+	RetryBaseDelay time.Duration
+	// RetryMaxDelay caps the backoff between retries
+	RetryMaxDelay time.Duration
+	// QPS is how many calls per second are made to one Argo CD endpoint, 0 does not limit them
+	QPS float64
+	// Burst is how many calls to one endpoint may be made at once before QPS applies
+	Burst int
+	// ProjectCacheTTL is how long a fetched project is handed out again to Tokens of the same project
+	ProjectCacheTTL time.Duration
+}
+
+// DefaultOptions returns the Options used when none are configured
+func DefaultOptions() Options {
+	return Options{
+		RequestTimeout:  30 * time.Second,
+		Timeout:         2 * time.Minute,
+		MaxRetries:      3,
+		RetryBaseDelay:  200 * time.Millisecond,
+		RetryMaxDelay:   5 * time.Second,
+		QPS:             10,
+		Burst:           20,
+		ProjectCacheTTL: time.Second,
 	}
+}
 
-	argoCDClient := Client{
-		client:      http.Client{Transport: transCfg},
-		loginCookie: loginCookie,
-		token:       token,
+// WithTimeout derives a context bounded by the overall Timeout, if one is set
+func (o Options) WithTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if o.Timeout <= 0 {
+		return context.WithCancel(ctx)
 	}
+	return context.WithTimeout(ctx, o.Timeout)
+}
 
-	return argoCDClient
+// projectAPI is the protocol specific part of talking to Argo CD's project service
+type projectAPI interface {
+	getProject(ctx context.Context, authTkn string, name string) (AppProject, error)
+	createToken(ctx context.Context, authTkn string, project string, role string, expiresIn int64) (string, error)
+	deleteToken(ctx context.Context, authTkn string, project string, role string, iat int64) error
+	updateProject(ctx context.Context, authTkn string, project AppProject) (AppProject, error)
 }
 
-// GetProject pings ArgoCD for the project which we will create a token for
-func (a *Client) GetProject() (AppProject, error) {
+// ArgoCDAPI is what the controller needs from Argo CD for the project and role of one Token
+type ArgoCDAPI interface {
+	// GetProject returns the Token's project
+	GetProject(ctx context.Context) (AppProject, error)
+	// GenerateToken issues a token for the Token's role, which must exist in project
+	GenerateToken(ctx context.Context, project AppProject) (string, error)
+	// ListTokens returns the tokens issued for the Token's role
+	ListTokens(ctx context.Context) ([]JWTToken, error)
+	// DeleteToken revokes a token issued for the Token's role
+	DeleteToken(ctx context.Context, token string) error
+	// DeleteTokenIssuedAt revokes the token of the Token's role issued at iat
+	DeleteTokenIssuedAt(ctx context.Context, iat int64) error
+	// CreateRole adds a role to the Token's project
+	CreateRole(ctx context.Context, role ProjectRole) error
+	// DeleteRole removes a role and its tokens from the Token's project
+	DeleteRole(ctx context.Context, name string) error
+}
 
-	argoCDEndpt := fmt.Sprintf("%s/api/v1/projects/%s", a.token.Spec.ArgoCDEndpt, a.token.Spec.Project)
+// ClientFactory hands out an ArgoCDAPI for a Token
+type ClientFactory interface {
+	Client(ctx context.Context, token argoprojlabsv1.Token) (ArgoCDAPI, error)
+}
 
-	request, err := http.NewRequest("GET", argoCDEndpt, nil)
+var _ ArgoCDAPI = &Client{}
 
-	request.AddCookie(&a.loginCookie)
+// Client holds the connection to Argo CD, the provider of the token used to login and a token object
+type Client struct {
+	api   projectAPI
+	auth  AuthProvider
+	token argoprojlabsv1.Token
+}
 
-	var project AppProject
+// NewArgoCDClient constructs a Client object with a connection of its own, using the protocol set on the Token.
+// Reconciles share pooled connections through a ClientCache instead. Calls are reported to breakers, which may be nil.
+func NewArgoCDClient(auth AuthProvider, token argoprojlabsv1.Token, opts Options, breakers *CircuitBreakers) (Client, error) {
 
-	response, err := a.client.Do(request)
+	limiter := NewRateLimiters(opts.QPS, opts.Burst).For(token.Spec.ArgoCDEndpt)
+	conn, err := newPooledConn(token.Spec.ArgoCDEndpt, token.Spec.Protocol, nil, opts, breakers, limiter)
 	if err != nil {
-		return project, err
+		return Client{}, err
 	}
 
-	defer response.Body.Close()
+	argoCDClient := Client{
+		api:   conn.api,
+		auth:  auth,
+		token: token,
+	}
 
-	body, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		return project, err
+	return argoCDClient, nil
+}
+
+// newHTTPClient wraps the transport with retries and the request timeout
+func newHTTPClient(transport http.RoundTripper, opts Options, breakers *CircuitBreakers) *http.Client {
+	return &http.Client{
+		Transport: &retryTransport{
+			retryPolicy: newRetryPolicy(opts),
+			next:        transport,
+			breakers:    breakers,
+		},
+		Timeout: opts.RequestTimeout,
+	}
+}
+
+// newTLSConfig builds the TLS configuration used for calls to Argo CD. Without a CA bundle the server
+// certificate is not verified, which keeps self-signed Argo CD installs working.
+func newTLSConfig(caPEM []byte) (*tls.Config, error) {
+	if len(caPEM) == 0 {
+		return &tls.Config{InsecureSkipVerify: true}, nil
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no certificates found in the Argo CD CA bundle")
 	}
+	return &tls.Config{RootCAs: pool}, nil
+}
 
-	err = json.Unmarshal(body, &project)
+// newTransport builds the pooled transport used for HTTP calls to Argo CD
+func newTransport(caPEM []byte) (*http.Transport, error) {
+	tlsConfig, err := newTLSConfig(caPEM)
 	if err != nil {
-		return project, err
+		return nil, err
 	}
 
-	return project, nil
+	return &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		TLSClientConfig:     tlsConfig,
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 10,
+		IdleConnTimeout:     90 * time.Second,
+		TLSHandshakeTimeout: 10 * time.Second,
+	}, nil
+}
+
+// GetProject pings ArgoCD for the project which we will create a token for
+func (a *Client) GetProject(ctx context.Context) (AppProject, error) {
+
+	var project AppProject
+
+	err := a.withAuth(ctx, func(authTkn string) error {
+		var err error
+		project, err = a.api.getProject(ctx, authTkn, a.token.Spec.Project)
+		return err
+	})
+
+	return project, err
 }
 
 // GenerateToken uses a project to create a token pertaining to a specified role
-func (a *Client) GenerateToken(project AppProject) (string, error) {
+func (a *Client) GenerateToken(ctx context.Context, project AppProject) (string, error) {
 
-	roleExistence := roleExists(a.token.Spec.Role, project)
+	roleExistence := RoleExists(a.token.Spec.Role, project)
 	if !roleExistence {
 		return "", fmt.Errorf("The role does not exist")
 	}
 
-	argoCDEndpt := fmt.Sprintf("%s/api/v1/projects/%s/roles/%s/token", a.token.Spec.ArgoCDEndpt, a.token.Spec.Project, a.token.Spec.Role)
+	var tkn string
 
-	postReq := PostRequest{
-		ExpiresIn: a.token.Spec.ExpiresIn,
-		Project:   a.token.Spec.Project,
-		Role:      a.token.Spec.Role,
-	}
+	err := a.withAuth(ctx, func(authTkn string) error {
+		var err error
+		tkn, err = a.api.createToken(ctx, authTkn, a.token.Spec.Project, a.token.Spec.Role, a.token.Spec.ExpiresInSeconds())
+		return err
+	})
 
-	bytePostReq, err := json.Marshal(postReq)
-	if err != nil {
-		return "", err
-	}
+	return tkn, err
+}
+
+// ListTokens returns the tokens Argo CD has issued for the role
+func (a *Client) ListTokens(ctx context.Context) ([]JWTToken, error) {
 
-	request, err := http.NewRequest("POST", argoCDEndpt, bytes.NewBuffer(bytePostReq))
+	project, err := a.GetProject(ctx)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	request.Header.Set("Content-Type", "application/json")
-	request.AddCookie(&a.loginCookie)
 
-	response, err := a.client.Do(request)
-	if err != nil {
-		return "", err
+	for _, role := range project.Spec.Roles {
+		if role.Name == a.token.Spec.Role {
+			return role.JWTTokens, nil
+		}
 	}
 
-	defer response.Body.Close()
+	return nil, fmt.Errorf("The role does not exist")
+}
 
-	body, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		return "", err
-	}
+// DeleteToken removes expired tokens from ArgoCD
+func (a *Client) DeleteToken(ctx context.Context, token string) error {
 
-	var tkn Token
-	err = json.Unmarshal(body, &tkn)
+	claims, err := jwt.ParseClaims(token)
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	return tkn.Token, nil
+	return a.DeleteTokenIssuedAt(ctx, claims.IssuedAt)
 }
 
-// DeleteToken removes expired tokens from ArgoCD
-func (a *Client) DeleteToken(token string) error {
+// DeleteTokenIssuedAt removes the role's token issued at iat from ArgoCD
+func (a *Client) DeleteTokenIssuedAt(ctx context.Context, iat int64) error {
 
-	tokenIAT := jwt.ReturnIAT(token)
+	return a.withAuth(ctx, func(authTkn string) error {
+		return a.api.deleteToken(ctx, authTkn, a.token.Spec.Project, a.token.Spec.Role, iat)
+	})
+}
 
-	argoCDEndpt := fmt.Sprintf("%s/api/v1/projects/%s/roles/%s/token/%d", a.token.Spec.ArgoCDEndpt, a.token.Spec.Project, a.token.Spec.Role, tokenIAT)
+// CreateRole adds a role to the project
+func (a *Client) CreateRole(ctx context.Context, role ProjectRole) error {
 
-	request, err := http.NewRequest("DELETE", argoCDEndpt, nil)
+	return a.withAuth(ctx, func(authTkn string) error {
+		project, err := a.api.getProject(ctx, authTkn, a.token.Spec.Project)
+		if err != nil {
+			return err
+		}
+		if RoleExists(role.Name, project) {
+			return fmt.Errorf("The role %s already exists", role.Name)
+		}
 
-	request.AddCookie(&a.loginCookie)
+		project.Spec.Roles = append(project.Spec.Roles, role)
+		_, err = a.api.updateProject(ctx, authTkn, project)
+		return err
+	})
+}
 
-	response, err := a.client.Do(request)
-	if err != nil {
+// DeleteRole removes a role and with it all of its tokens from the project
+func (a *Client) DeleteRole(ctx context.Context, name string) error {
+
+	return a.withAuth(ctx, func(authTkn string) error {
+		project, err := a.api.getProject(ctx, authTkn, a.token.Spec.Project)
+		if err != nil {
+			return err
+		}
+		if !RoleExists(name, project) {
+			return fmt.Errorf("The role %s does not exist", name)
+		}
+
+		roles := make([]ProjectRole, 0, len(project.Spec.Roles)-1)
+		for _, role := range project.Spec.Roles {
+			if role.Name != name {
+				roles = append(roles, role)
+			}
+		}
+		project.Spec.Roles = roles
+		_, err = a.api.updateProject(ctx, authTkn, project)
 		return err
-	}
+	})
+}
+
+// withAuth runs call with the current auth token. When Argo CD rejects it the cached auth token
+// is dropped and, if the provider may hand out a different one, the call is retried once.
+func (a *Client) withAuth(ctx context.Context, call func(authTkn string) error) error {
 
-	defer response.Body.Close()
+	for attempt := 0; ; attempt++ {
+		authTkn, err := a.auth.Token(ctx, a.token.Spec.ArgoCDEndpt)
+		if err != nil {
+			return err
+		}
+
+		err = call(authTkn)
+		if err != ErrUnauthorized {
+			return err
+		}
 
-	return err
+		if !a.auth.Invalidate(a.token.Spec.ArgoCDEndpt) || attempt > 0 {
+			return err
+		}
+	}
 }
 
-// check if the role exists within the given project
-func roleExists(roleName string, project AppProject) bool {
+// RoleExists checks if the role exists within the given project
+func RoleExists(roleName string, project AppProject) bool {
 
 	for i := range project.Spec.Roles {
 		if project.Spec.Roles[i].Name == roleName {
@@ -230,3 +342,22 @@ func roleExists(roleName string, project AppProject) bool {
 
 	return false
 }
+
+// TokenRevoked checks if the token of the role issued at iat is no longer listed in the given project.
+// A missing role does not count as a revocation.
+func TokenRevoked(roleName string, iat int64, project AppProject) bool {
+
+	for i := range project.Spec.Roles {
+		if project.Spec.Roles[i].Name != roleName {
+			continue
+		}
+		for _, jwtToken := range project.Spec.Roles[i].JWTTokens {
+			if jwtToken.IssuedAt == iat {
+				return false
+			}
+		}
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,46 @@
+package argocd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	argoprojlabsv1 "github.com/argoproj-labs/argo-cd-tokens/api/v1"
+)
+
+func TestClientTimeouts(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// hang until the test is over, like an unresponsive Argo CD
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	var token argoprojlabsv1.Token
+	token.Spec.ArgoCDEndpt = server.URL
+	token.Spec.Project = "default"
+
+	// a single call gives up after RequestTimeout
+	argoCDClient, err := NewArgoCDClient(NewStaticAuth("tkn"), token, Options{RequestTimeout: 50 * time.Millisecond}, nil)
+	assert.Nil(t, err)
+	start := time.Now()
+	_, err = argoCDClient.GetProject(context.Background())
+	assert.NotNil(t, err)
+	assert.True(t, time.Since(start) < 5*time.Second)
+
+	// the overall Timeout cancels calls through the context
+	opts := Options{Timeout: 50 * time.Millisecond}
+	argoCDClient, err = NewArgoCDClient(NewStaticAuth("tkn"), token, opts, nil)
+	assert.Nil(t, err)
+	ctx, cancel := opts.WithTimeout(context.Background())
+	defer cancel()
+	start = time.Now()
+	_, err = argoCDClient.GetProject(ctx)
+	assert.NotNil(t, err)
+	assert.True(t, time.Since(start) < 5*time.Second)
+}
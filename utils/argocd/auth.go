@@ -0,0 +1,252 @@
+package argocd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// UsernameKey is the Secret key holding the Argo CD username used by SessionAuth
+	UsernameKey = "username"
+	// PasswordKey is the Secret key holding the Argo CD password used by SessionAuth
+	PasswordKey = "password"
+)
+
+// AuthProvider supplies the token the Client authenticates to Argo CD with
+type AuthProvider interface {
+	// Token returns a token that is valid for the given Argo CD endpoint
+	Token(ctx context.Context, endpoint string) (string, error)
+	// Invalidate drops any cached token for the endpoint so the next call to Token fetches a new one. It reports
+	// whether that may be a different token, which makes retrying a call Argo CD rejected worthwhile.
+	Invalidate(endpoint string) bool
+}
+
+// StaticAuth hands out a single pre-minted token, e.g. the one in AUTH_TKN
+type StaticAuth struct {
+	token string
+}
+
+// NewStaticAuth constructs a StaticAuth object
+func NewStaticAuth(token string) *StaticAuth {
+	return &StaticAuth{token: token}
+}
+
+// Token returns the pre-minted token regardless of endpoint
+func (s *StaticAuth) Token(ctx context.Context, endpoint string) (string, error) {
+	if s.token == "" {
+		return "", fmt.Errorf("no Argo CD auth token configured")
+	}
+	return s.token, nil
+}
+
+// Invalidate is a no-op since a static token cannot be refreshed
+func (s *StaticAuth) Invalidate(endpoint string) bool {
+	return false
+}
+
+// Credentials are the username and password of a local Argo CD account
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// CredentialsFunc loads the credentials SessionAuth logs in with
+type CredentialsFunc func(ctx context.Context) (Credentials, error)
+
+// SecretCredentials loads credentials from the username and password keys of a Secret.
+// The Secret is read on every login so a changed password is picked up without a restart.
+func SecretCredentials(reader client.Reader, secretName types.NamespacedName) CredentialsFunc {
+	return func(ctx context.Context) (Credentials, error) {
+		var secret corev1.Secret
+		err := reader.Get(ctx, secretName, &secret)
+		if err != nil {
+			return Credentials{}, err
+		}
+		creds := Credentials{
+			Username: string(secret.Data[UsernameKey]),
+			Password: string(secret.Data[PasswordKey]),
+		}
+		if creds.Username == "" || creds.Password == "" {
+			return Credentials{}, fmt.Errorf("Secret %s is missing %s or %s", secretName, UsernameKey, PasswordKey)
+		}
+		return creds, nil
+	}
+}
+
+// SessionAuth logs in through Argo CD's session API and caches the session token per endpoint
+type SessionAuth struct {
+	timeout     time.Duration
+	caFile      string
+	credentials CredentialsFunc
+
+	mu       sync.Mutex
+	sessions map[string]string
+	// transport verifies Argo CD's certificate against caPEM, it is rebuilt when the CA bundle changes
+	transport *http.Transport
+	caPEM     []byte
+	// logins serializes the logins to each endpoint, so a slow endpoint doesn't hold up the others
+	logins map[string]*sync.Mutex
+}
+
+// sessionRequest is the payload of POST /api/v1/session
+type sessionRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// NewSessionAuth constructs a SessionAuth object. Logins are bounded by the RequestTimeout of opts. Argo CD's
+// certificate is verified against the CA bundle in caFile like ClientCache does, caFile may be empty to skip it.
+func NewSessionAuth(credentials CredentialsFunc, opts Options, caFile string) *SessionAuth {
+	return &SessionAuth{
+		timeout:     opts.RequestTimeout,
+		caFile:      caFile,
+		credentials: credentials,
+		sessions:    make(map[string]string),
+		logins:      make(map[string]*sync.Mutex),
+	}
+}
+
+// Token returns the cached session token for the endpoint, logging in if there is none
+func (s *SessionAuth) Token(ctx context.Context, endpoint string) (string, error) {
+	if tkn, ok := s.session(endpoint); ok {
+		return tkn, nil
+	}
+
+	login := s.loginLock(endpoint)
+	login.Lock()
+	defer login.Unlock()
+
+	// another reconcile may have logged in while this one waited
+	if tkn, ok := s.session(endpoint); ok {
+		return tkn, nil
+	}
+
+	tkn, err := s.login(ctx, endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[endpoint] = tkn
+	return tkn, nil
+}
+
+// Invalidate forgets the session for the endpoint so the next call to Token logs in again
+func (s *SessionAuth) Invalidate(endpoint string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, endpoint)
+	return true
+}
+
+// session returns the cached session token for the endpoint
+func (s *SessionAuth) session(endpoint string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tkn, ok := s.sessions[endpoint]
+	return tkn, ok
+}
+
+// loginLock returns the lock serializing the logins to the endpoint
+func (s *SessionAuth) loginLock(endpoint string) *sync.Mutex {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	login, ok := s.logins[endpoint]
+	if !ok {
+		login = &sync.Mutex{}
+		s.logins[endpoint] = login
+	}
+	return login
+}
+
+// httpClient returns the client logins are posted with, verifying Argo CD's certificate against the current CA bundle.
+// An unreadable CA bundle fails the login instead of posting the credentials unverified.
+func (s *SessionAuth) httpClient() (*http.Client, error) {
+	caPEM, err := readCAFile(s.caFile)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.transport == nil || !bytes.Equal(s.caPEM, caPEM) {
+		transport, err := newTransport(caPEM)
+		if err != nil {
+			return nil, err
+		}
+		if s.transport != nil {
+			s.transport.CloseIdleConnections()
+		}
+		s.transport = transport
+		s.caPEM = caPEM
+	}
+	return &http.Client{Transport: s.transport, Timeout: s.timeout}, nil
+}
+
+// login exchanges the credentials for a session token
+func (s *SessionAuth) login(ctx context.Context, endpoint string) (string, error) {
+
+	creds, err := s.credentials(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	byteReq, err := json.Marshal(sessionRequest{Username: creds.Username, Password: creds.Password})
+	if err != nil {
+		return "", err
+	}
+
+	argoCDEndpt := fmt.Sprintf("%s/api/v1/session", endpoint)
+
+	request, err := http.NewRequestWithContext(ctx, "POST", argoCDEndpt, bytes.NewBuffer(byteReq))
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	httpClient, err := s.httpClient()
+	if err != nil {
+		return "", err
+	}
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return "", err
+	}
+
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return "", err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Argo CD login as %s failed: %s", creds.Username, response.Status)
+	}
+
+	var tkn Token
+	err = json.Unmarshal(body, &tkn)
+	if err != nil {
+		return "", err
+	}
+	if tkn.Token == "" {
+		return "", fmt.Errorf("Argo CD login as %s returned no token", creds.Username)
+	}
+
+	return tkn.Token, nil
+}
@@ -0,0 +1,221 @@
+package argocd
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	argoprojlabsv1 "github.com/argoproj-labs/argo-cd-tokens/api/v1"
+)
+
+func TestSessionAuth(t *testing.T) {
+	logins := 0
+	validSession := ""
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/session":
+			var req sessionRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			if req.Username != "admin" || req.Password != "password" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			logins++
+			validSession = fmt.Sprintf("session-%d", logins)
+			_ = json.NewEncoder(w).Encode(Token{Token: validSession})
+		case "/api/v1/projects/default":
+			cookie, err := r.Cookie("argocd.token")
+			if err != nil || cookie.Value != validSession {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(AppProject{})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	auth := NewSessionAuth(func(ctx context.Context) (Credentials, error) {
+		return Credentials{Username: "admin", Password: "password"}, nil
+	}, DefaultOptions(), "")
+
+	ctx := context.Background()
+	var token argoprojlabsv1.Token
+	token.Spec.ArgoCDEndpt = server.URL
+	token.Spec.Project = "default"
+	// every GetProject reaches Argo CD instead of reusing the fetched project
+	opts := DefaultOptions()
+	opts.ProjectCacheTTL = 0
+	argoCDClient, err := NewArgoCDClient(auth, token, opts, nil)
+	assert.Nil(t, err)
+
+	_, err = argoCDClient.GetProject(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, logins)
+
+	// the cached session is reused
+	_, err = argoCDClient.GetProject(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, logins)
+
+	// an expired session triggers a single new login
+	validSession = "expired"
+	_, err = argoCDClient.GetProject(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, logins)
+
+	badAuth := NewSessionAuth(func(ctx context.Context) (Credentials, error) {
+		return Credentials{Username: "admin", Password: "wrong"}, nil
+	}, DefaultOptions(), "")
+	_, err = badAuth.Token(ctx, server.URL)
+	assert.NotNil(t, err)
+}
+
+func TestStaticAuthNotRetried(t *testing.T) {
+	calls := 0
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	var token argoprojlabsv1.Token
+	token.Spec.ArgoCDEndpt = server.URL
+	token.Spec.Project = "default"
+	argoCDClient, err := NewArgoCDClient(NewStaticAuth("rejected"), token, DefaultOptions(), nil)
+	assert.Nil(t, err)
+
+	// a static token can't be refreshed, so the rejected call isn't made again
+	_, err = argoCDClient.GetProject(context.Background())
+	assert.True(t, IsUnauthorized(err))
+	assert.Equal(t, 1, calls)
+}
+
+func TestSessionAuthLoginsPerEndpoint(t *testing.T) {
+	stalled := make(chan struct{})
+	slow := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-stalled
+		_ = json.NewEncoder(w).Encode(Token{Token: "slow"})
+	}))
+	defer slow.Close()
+	defer close(stalled)
+	fast := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(Token{Token: "fast"})
+	}))
+	defer fast.Close()
+
+	auth := NewSessionAuth(func(ctx context.Context) (Credentials, error) {
+		return Credentials{Username: "admin", Password: "password"}, nil
+	}, DefaultOptions(), "")
+	ctx := context.Background()
+	go func() { _, _ = auth.Token(ctx, slow.URL) }()
+
+	// a login hanging on one endpoint doesn't hold up the others
+	done := make(chan string)
+	go func() {
+		tkn, _ := auth.Token(ctx, fast.URL)
+		done <- tkn
+	}()
+	select {
+	case tkn := <-done:
+		assert.Equal(t, "fast", tkn)
+	case <-time.After(5 * time.Second):
+		t.Fatal("login to one endpoint waited for another")
+	}
+}
+
+func TestSessionAuthRequestTimeout(t *testing.T) {
+	stalled := make(chan struct{})
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-stalled
+	}))
+	defer server.Close()
+	defer close(stalled)
+
+	opts := DefaultOptions()
+	opts.RequestTimeout = 50 * time.Millisecond
+	auth := NewSessionAuth(func(ctx context.Context) (Credentials, error) {
+		return Credentials{Username: "admin", Password: "password"}, nil
+	}, opts, "")
+
+	start := time.Now()
+	_, err := auth.Token(context.Background(), server.URL)
+	assert.NotNil(t, err)
+	assert.True(t, time.Since(start) < 5*time.Second)
+}
+
+func TestSessionAuthVerifiesCertificate(t *testing.T) {
+	posted := 0
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posted++
+		_ = json.NewEncoder(w).Encode(Token{Token: "session"})
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "argocd-ca")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	caFile := filepath.Join(dir, "ca.crt")
+	writeCA := func(cert []byte) {
+		caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert})
+		assert.Nil(t, ioutil.WriteFile(caFile, caPEM, 0600))
+	}
+	credentials := func(ctx context.Context) (Credentials, error) {
+		return Credentials{Username: "admin", Password: "password"}, nil
+	}
+	ctx := context.Background()
+
+	// the credentials are only posted to a server whose certificate the CA bundle vouches for
+	writeCA(otherCertificate(t))
+	auth := NewSessionAuth(credentials, DefaultOptions(), caFile)
+	_, err = auth.Token(ctx, server.URL)
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, posted)
+
+	// a rotated CA bundle is picked up on the next login
+	writeCA(server.Certificate().Raw)
+	tkn, err := auth.Token(ctx, server.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, "session", tkn)
+	assert.Equal(t, 1, posted)
+
+	// an unreadable CA bundle fails the login instead of skipping verification
+	_, err = NewSessionAuth(credentials, DefaultOptions(), filepath.Join(dir, "missing.crt")).Token(ctx, server.URL)
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, posted)
+}
+
+// otherCertificate returns a self-signed certificate other than the one of httptest servers
+func otherCertificate(t *testing.T) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "other"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.Nil(t, err)
+	return cert
+}
@@ -0,0 +1,264 @@
+package argocd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc"
+
+	argoprojlabsv1 "github.com/argoproj-labs/argo-cd-tokens/api/v1"
+)
+
+// ClientCache shares one pooled connection per Argo CD endpoint and protocol between all reconciles, and with it
+// the endpoint's rate limiter and recently fetched projects. Tokens with a credentialsRef get connections of
+// their own for each credential.
+// A connection is rebuilt whenever the credential used for the endpoint or the CA bundle changes. The old one is
+// retired: it is closed once the reconciles that may still hold it are past their deadline and none of its calls is
+// in flight.
+type ClientCache struct {
+	auth        AuthProvider
+	opts        Options
+	breakers    *CircuitBreakers
+	limiters    *RateLimiters
+	caFile      string
+	credentials CredentialsRefFunc
+
+	mu    sync.Mutex
+	conns map[connKey]*pooledConn
+}
+
+// connKey identifies a pooled connection
+type connKey struct {
+	endpoint string
+	protocol string
+	// credentialsRef identifies the credentialsRef the connection authenticates with, if any
+	credentialsRef string
+}
+
+// pooledConn is the shared connection to one endpoint
+type pooledConn struct {
+	fingerprint string
+	transport   *http.Transport
+	grpcConn    *grpc.ClientConn
+	api         projectAPI
+
+	mu sync.Mutex
+	// calls is how many calls are in flight on the connection
+	calls   int
+	retired bool
+	closed  bool
+}
+
+// newPooledConn opens a connection to the endpoint that speaks the given protocol
+func newPooledConn(endpoint string, protocol string, caPEM []byte, opts Options, breakers *CircuitBreakers, limiter *rate.Limiter) (*pooledConn, error) {
+	transport, err := newTransport(caPEM)
+	if err != nil {
+		return nil, err
+	}
+	conn := &pooledConn{transport: transport}
+
+	switch protocol {
+	case "", argoprojlabsv1.ProtocolREST:
+		conn.api = &restAPI{client: newHTTPClient(transport, opts, breakers), endpoint: endpoint}
+	case argoprojlabsv1.ProtocolGRPCWeb:
+		conn.api = &grpcProjectAPI{invoke: grpcWebInvoker(newHTTPClient(transport, opts, breakers), endpoint), retry: newRetryPolicy(opts)}
+	case argoprojlabsv1.ProtocolGRPC:
+		conn.grpcConn, err = dialGRPC(endpoint, transport.TLSClientConfig)
+		if err != nil {
+			return nil, err
+		}
+		conn.api = &grpcProjectAPI{invoke: nativeGRPCInvoker(conn.grpcConn, breakers, endpoint, opts.RequestTimeout), retry: newRetryPolicy(opts)}
+	default:
+		return nil, fmt.Errorf("unknown Argo CD protocol %q", protocol)
+	}
+	conn.api = &countedAPI{next: newThrottledAPI(conn.api, limiter, opts.ProjectCacheTTL), conn: conn}
+
+	return conn, nil
+}
+
+// retire closes a replaced or dropped pooledConn after grace, or once the last call in flight then finished
+func (p *pooledConn) retire(grace time.Duration) {
+	time.AfterFunc(grace, func() {
+		p.mu.Lock()
+		p.retired = true
+		p.mu.Unlock()
+		p.closeIfIdle()
+	})
+}
+
+// begin counts a call on the connection until end is called
+func (p *pooledConn) begin() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.calls++
+}
+
+func (p *pooledConn) end() {
+	p.mu.Lock()
+	p.calls--
+	p.mu.Unlock()
+	p.closeIfIdle()
+}
+
+// closeIfIdle releases the connections of a retired pooledConn that has no call in flight
+func (p *pooledConn) closeIfIdle() {
+	p.mu.Lock()
+	idle := p.retired && p.calls == 0 && !p.closed
+	p.closed = p.closed || idle
+	p.mu.Unlock()
+
+	if !idle {
+		return
+	}
+	p.transport.CloseIdleConnections()
+	if p.grpcConn != nil {
+		p.grpcConn.Close()
+	}
+}
+
+// countedAPI tracks the calls in flight on a pooledConn, so it isn't closed under them
+type countedAPI struct {
+	next projectAPI
+	conn *pooledConn
+}
+
+func (c *countedAPI) getProject(ctx context.Context, authTkn string, name string) (AppProject, error) {
+	c.conn.begin()
+	defer c.conn.end()
+	return c.next.getProject(ctx, authTkn, name)
+}
+
+func (c *countedAPI) createToken(ctx context.Context, authTkn string, project string, role string, expiresIn int64) (string, error) {
+	c.conn.begin()
+	defer c.conn.end()
+	return c.next.createToken(ctx, authTkn, project, role, expiresIn)
+}
+
+func (c *countedAPI) deleteToken(ctx context.Context, authTkn string, project string, role string, iat int64) error {
+	c.conn.begin()
+	defer c.conn.end()
+	return c.next.deleteToken(ctx, authTkn, project, role, iat)
+}
+
+func (c *countedAPI) updateProject(ctx context.Context, authTkn string, project AppProject) (AppProject, error) {
+	c.conn.begin()
+	defer c.conn.end()
+	return c.next.updateProject(ctx, authTkn, project)
+}
+
+// retireGrace is how long a Client handed out may still be used: for the Timeout of one reconcile, or the default
+// one if reconciles aren't bounded
+func (c *ClientCache) retireGrace() time.Duration {
+	if c.opts.Timeout > 0 {
+		return c.opts.Timeout
+	}
+	return DefaultOptions().Timeout
+}
+
+// NewClientCache constructs a ClientCache object. caFile may be empty to skip verifying Argo CD's certificate.
+// credentials may be nil to authenticate every Token with auth, ignoring credentialsRef.
+func NewClientCache(auth AuthProvider, opts Options, breakers *CircuitBreakers, caFile string, credentials CredentialsRefFunc) *ClientCache {
+	return &ClientCache{
+		auth:        auth,
+		opts:        opts,
+		breakers:    breakers,
+		limiters:    NewRateLimiters(opts.QPS, opts.Burst),
+		caFile:      caFile,
+		credentials: credentials,
+		conns:       make(map[connKey]*pooledConn),
+	}
+}
+
+// Client returns a Client for the Token that uses the pooled connection of the Token's endpoint
+func (c *ClientCache) Client(ctx context.Context, token argoprojlabsv1.Token) (ArgoCDAPI, error) {
+	endpoint := token.Spec.ArgoCDEndpt
+	key := connKey{endpoint: endpoint, protocol: token.Spec.Protocol}
+
+	caPEM, err := c.readCA()
+	if err != nil {
+		return nil, err
+	}
+
+	auth := c.auth
+	if ref := token.Spec.CredentialsRef; ref != nil && c.credentials != nil {
+		credential, err := c.credentials(ctx, token)
+		if err != nil {
+			return nil, err
+		}
+		auth = NewStaticAuth(credential)
+		key.credentialsRef = token.Namespace + "/" + ref.Name + "/" + ref.Key
+	}
+
+	authTkn, err := auth.Token(ctx, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	fingerprint := fingerprintOf(authTkn, caPEM)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	conn, ok := c.conns[key]
+	if !ok || conn.fingerprint != fingerprint {
+		newConn, err := newPooledConn(endpoint, token.Spec.Protocol, caPEM, c.opts, c.breakers, c.limiters.For(endpoint))
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			conn.retire(c.retireGrace())
+		}
+		newConn.fingerprint = fingerprint
+		conn = newConn
+		c.conns[key] = conn
+	}
+
+	return &Client{
+		api:   conn.api,
+		auth:  auth,
+		token: token,
+	}, nil
+}
+
+// Invalidate drops the pooled connections of an endpoint
+func (c *ClientCache) Invalidate(endpoint string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, conn := range c.conns {
+		if key.endpoint == endpoint {
+			conn.retire(c.retireGrace())
+			delete(c.conns, key)
+		}
+	}
+}
+
+// readCA reads the CA bundle on every call so a rotated CA is picked up without a restart
+func (c *ClientCache) readCA() ([]byte, error) {
+	return readCAFile(c.caFile)
+}
+
+// readCAFile reads the CA bundle Argo CD's certificate is verified against, or returns nil if caFile is empty
+func readCAFile(caFile string) ([]byte, error) {
+	if caFile == "" {
+		return nil, nil
+	}
+	return ioutil.ReadFile(caFile)
+}
+
+// fingerprintOf hashes everything a pooled connection depends on, without keeping the credential around
+func fingerprintOf(authTkn string, caPEM []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(authTkn))
+	hash.Write([]byte{0})
+	hash.Write(caPEM)
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,165 @@
+package argocd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	corev1 "k8s.io/api/core/v1"
+
+	argoprojlabsv1 "github.com/argoproj-labs/argo-cd-tokens/api/v1"
+	"github.com/argoproj-labs/argo-cd-tokens/utils/argocd/project"
+)
+
+func TestClientCache(t *testing.T) {
+	auth := &countingAuth{token: "first"}
+	cache := NewClientCache(auth, DefaultOptions(), nil, "", nil)
+	ctx := context.Background()
+
+	var token, otherToken, otherEndpoint argoprojlabsv1.Token
+	token.Spec.ArgoCDEndpt = "https://argocd.example.com"
+	otherToken.Spec.ArgoCDEndpt = "https://argocd.example.com"
+	otherToken.Spec.Project = "other"
+	otherEndpoint.Spec.ArgoCDEndpt = "https://other.example.com"
+
+	first, err := cache.Client(ctx, token)
+	assert.Nil(t, err)
+
+	// Tokens on the same endpoint share the pooled connection
+	second, err := cache.Client(ctx, otherToken)
+	assert.Nil(t, err)
+	assert.True(t, first.(*Client).api == second.(*Client).api)
+	assert.Equal(t, "other", second.(*Client).token.Spec.Project)
+
+	third, err := cache.Client(ctx, otherEndpoint)
+	assert.Nil(t, err)
+	assert.False(t, first.(*Client).api == third.(*Client).api)
+
+	// a new credential gets a new connection
+	auth.token = "second"
+	fourth, err := cache.Client(ctx, token)
+	assert.Nil(t, err)
+	assert.False(t, first.(*Client).api == fourth.(*Client).api)
+
+	cache.Invalidate(token.Spec.ArgoCDEndpt)
+	fifth, err := cache.Client(ctx, token)
+	assert.Nil(t, err)
+	assert.False(t, fourth.(*Client).api == fifth.(*Client).api)
+
+	// an unreadable CA bundle is reported instead of silently skipping verification
+	_, err = NewClientCache(auth, DefaultOptions(), nil, "/does/not/exist", nil).Client(ctx, token)
+	assert.NotNil(t, err)
+}
+
+// rotatingAuth hands out a new credential every time rotate is called
+type rotatingAuth struct {
+	generation int64
+}
+
+func (r *rotatingAuth) Token(ctx context.Context, endpoint string) (string, error) {
+	return fmt.Sprintf("tkn-%d", atomic.LoadInt64(&r.generation)), nil
+}
+
+func (r *rotatingAuth) Invalidate(endpoint string) bool {
+	return false
+}
+
+func (r *rotatingAuth) rotate() {
+	atomic.AddInt64(&r.generation, 1)
+}
+
+func TestClientCacheRetiresConnections(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+
+	fakeServer := &fakeProjectServer{t: t}
+	server := grpc.NewServer()
+	project.RegisterProjectServiceServer(server, fakeServer)
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+
+	var token argoprojlabsv1.Token
+	token.Spec.ArgoCDEndpt = "http://" + listener.Addr().String()
+	token.Spec.Project = "default"
+	token.Spec.Protocol = argoprojlabsv1.ProtocolGRPC
+	auth := &rotatingAuth{}
+	opts := DefaultOptions()
+	opts.Timeout = 200 * time.Millisecond
+	opts.ProjectCacheTTL = 0
+	opts.MaxRetries = 0
+	cache := NewClientCache(auth, opts, nil, "", nil)
+	ctx := context.Background()
+
+	first, err := cache.Client(ctx, token)
+	assert.Nil(t, err)
+	firstConn := first.(*Client).api.(*countedAPI).conn
+
+	// calls of concurrent reconciles still holding a replaced connection aren't cut off
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				argoCDClient, err := cache.Client(ctx, token)
+				if !assert.Nil(t, err) {
+					return
+				}
+				_, err = argoCDClient.GetProject(ctx)
+				assert.Nil(t, err)
+			}
+		}()
+	}
+	for i := 0; i < 20; i++ {
+		auth.rotate()
+		time.Sleep(time.Millisecond)
+	}
+	wg.Wait()
+
+	// once its grace period passed the replaced connection is closed
+	deadline := time.Now().Add(2 * time.Second)
+	for firstConn.grpcConn.GetState() != connectivity.Shutdown && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, connectivity.Shutdown, firstConn.grpcConn.GetState())
+}
+
+func TestClientCacheCredentialsRef(t *testing.T) {
+	auth := &countingAuth{token: "controller"}
+	credentials := func(ctx context.Context, token argoprojlabsv1.Token) (string, error) {
+		return "tenant", nil
+	}
+	cache := NewClientCache(auth, DefaultOptions(), nil, "", credentials)
+	ctx := context.Background()
+
+	var token, tenantToken argoprojlabsv1.Token
+	token.Spec.ArgoCDEndpt = "https://argocd.example.com"
+	tenantToken.Spec.ArgoCDEndpt = "https://argocd.example.com"
+	tenantToken.Spec.CredentialsRef = &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: "argocd-credentials"},
+		Key:                  "token",
+	}
+
+	first, err := cache.Client(ctx, token)
+	assert.Nil(t, err)
+	second, err := cache.Client(ctx, tenantToken)
+	assert.Nil(t, err)
+
+	// a credentialsRef authenticates on a connection of its own
+	assert.False(t, first.(*Client).api == second.(*Client).api)
+	authTkn, err := second.(*Client).auth.Token(ctx, tenantToken.Spec.ArgoCDEndpt)
+	assert.Nil(t, err)
+	assert.Equal(t, "tenant", authTkn)
+
+	// without a CredentialsRefFunc every Token uses the controller's credential
+	third, err := NewClientCache(auth, DefaultOptions(), nil, "", nil).Client(ctx, tenantToken)
+	assert.Nil(t, err)
+	assert.True(t, third.(*Client).auth == AuthProvider(auth))
+}
@@ -0,0 +1,199 @@
+package argocd
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	argoprojlabsv1 "github.com/argoproj-labs/argo-cd-tokens/api/v1"
+)
+
+// AppProjectGVK is the kind of the AppProject objects Argo CD keeps its projects in
+var AppProjectGVK = schema.GroupVersionKind{Group: "argoproj.io", Version: "v1alpha1", Kind: "AppProject"}
+
+// NewAppProjectObject returns an empty AppProject object to read or watch through a controller-runtime client
+func NewAppProjectObject() *unstructured.Unstructured {
+	object := &unstructured.Unstructured{}
+	object.SetGroupVersionKind(AppProjectGVK)
+	return object
+}
+
+// ProjectFromObject converts an AppProject object into an AppProject
+func ProjectFromObject(object *unstructured.Unstructured) (AppProject, error) {
+	var project AppProject
+	err := runtime.DefaultUnstructuredConverter.FromUnstructured(object.Object, &project)
+	return project, err
+}
+
+// RolesChanged reports whether a role was added to or removed from the project, or the tokens of a role changed
+func RolesChanged(old AppProject, new AppProject) bool {
+	if len(old.Spec.Roles) != len(new.Spec.Roles) {
+		return true
+	}
+
+	oldTokens := make(map[string][]JWTToken, len(old.Spec.Roles))
+	for _, role := range old.Spec.Roles {
+		oldTokens[role.Name] = role.JWTTokens
+	}
+	for _, role := range new.Spec.Roles {
+		tokens, ok := oldTokens[role.Name]
+		if !ok || len(tokens) != len(role.JWTTokens) {
+			return true
+		}
+		for i := range tokens {
+			if tokens[i] != role.JWTTokens[i] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ClusterProjects reads the projects of an Argo CD running in the same cluster from its AppProject objects,
+// which saves the call to Argo CD every reconcile makes to look up the Token's role
+type ClusterProjects struct {
+	reader    client.Reader
+	namespace string
+	endpoint  string
+
+	mu sync.Mutex
+	// changed holds the resourceVersion each project had when the controller last changed it through Argo CD.
+	// Until the reader has seen a newer one, the project is fetched from Argo CD instead.
+	changed map[types.NamespacedName]string
+}
+
+// NewClusterProjects constructs a ClusterProjects object reading the AppProjects in namespace. It only serves the
+// Tokens, and instances, of endpoint: a Token of another Argo CD would never find its tokens in these projects.
+func NewClusterProjects(reader client.Reader, namespace string, endpoint string) *ClusterProjects {
+	return &ClusterProjects{
+		reader:    reader,
+		namespace: namespace,
+		endpoint:  endpoint,
+		changed:   make(map[types.NamespacedName]string),
+	}
+}
+
+// Serves reports whether the projects of endpoint are read from the cluster
+func (c *ClusterProjects) Serves(endpoint string) bool {
+	if c == nil || c.endpoint == "" {
+		return false
+	}
+	return hostOf(c.endpoint) == hostOf(endpoint)
+}
+
+// Namespace returns the namespace of the AppProjects
+func (c *ClusterProjects) Namespace() string {
+	return c.namespace
+}
+
+// Client returns an ArgoCDAPI for the Token that reads its project from the cluster and makes every other call
+// through api
+func (c *ClusterProjects) Client(api ArgoCDAPI, token argoprojlabsv1.Token) ArgoCDAPI {
+	return &clusterProjectClient{ArgoCDAPI: api, projects: c, token: token}
+}
+
+// get returns the project, and false if it is being changed and has to be fetched from Argo CD
+func (c *ClusterProjects) get(ctx context.Context, name string) (AppProject, bool, error) {
+	key := types.NamespacedName{Namespace: c.namespace, Name: name}
+	object := NewAppProjectObject()
+	err := c.reader.Get(ctx, key, object)
+	if apierrors.IsNotFound(err) {
+		return AppProject{}, true, fmt.Errorf("The project %s does not exist", name)
+	}
+	if err != nil {
+		return AppProject{}, false, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if version, ok := c.changed[key]; ok {
+		if version == object.GetResourceVersion() {
+			return AppProject{}, false, nil
+		}
+		delete(c.changed, key)
+	}
+
+	project, err := ProjectFromObject(object)
+	return project, true, err
+}
+
+// changing records that the controller is about to change the project through Argo CD
+func (c *ClusterProjects) changing(ctx context.Context, name string) {
+	key := types.NamespacedName{Namespace: c.namespace, Name: name}
+	object := NewAppProjectObject()
+	if err := c.reader.Get(ctx, key, object); err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.changed[key] = object.GetResourceVersion()
+}
+
+// clusterProjectClient is the ArgoCDAPI of a Token whose project is read from the cluster
+type clusterProjectClient struct {
+	ArgoCDAPI
+	projects *ClusterProjects
+	token    argoprojlabsv1.Token
+}
+
+// GetProject implements ArgoCDAPI
+func (c *clusterProjectClient) GetProject(ctx context.Context) (AppProject, error) {
+	project, cached, err := c.projects.get(ctx, c.token.Spec.Project)
+	if err != nil || cached {
+		return project, err
+	}
+	return c.ArgoCDAPI.GetProject(ctx)
+}
+
+// ListTokens implements ArgoCDAPI
+func (c *clusterProjectClient) ListTokens(ctx context.Context) ([]JWTToken, error) {
+	project, err := c.GetProject(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, role := range project.Spec.Roles {
+		if role.Name == c.token.Spec.Role {
+			return role.JWTTokens, nil
+		}
+	}
+	return nil, fmt.Errorf("The role does not exist")
+}
+
+// GenerateToken implements ArgoCDAPI
+func (c *clusterProjectClient) GenerateToken(ctx context.Context, project AppProject) (string, error) {
+	c.projects.changing(ctx, c.token.Spec.Project)
+	return c.ArgoCDAPI.GenerateToken(ctx, project)
+}
+
+// DeleteToken implements ArgoCDAPI
+func (c *clusterProjectClient) DeleteToken(ctx context.Context, token string) error {
+	c.projects.changing(ctx, c.token.Spec.Project)
+	return c.ArgoCDAPI.DeleteToken(ctx, token)
+}
+
+// DeleteTokenIssuedAt implements ArgoCDAPI
+func (c *clusterProjectClient) DeleteTokenIssuedAt(ctx context.Context, iat int64) error {
+	c.projects.changing(ctx, c.token.Spec.Project)
+	return c.ArgoCDAPI.DeleteTokenIssuedAt(ctx, iat)
+}
+
+// CreateRole implements ArgoCDAPI
+func (c *clusterProjectClient) CreateRole(ctx context.Context, role ProjectRole) error {
+	c.projects.changing(ctx, c.token.Spec.Project)
+	return c.ArgoCDAPI.CreateRole(ctx, role)
+}
+
+// DeleteRole implements ArgoCDAPI
+func (c *clusterProjectClient) DeleteRole(ctx context.Context, name string) error {
+	c.projects.changing(ctx, c.token.Spec.Project)
+	return c.ArgoCDAPI.DeleteRole(ctx, name)
+}
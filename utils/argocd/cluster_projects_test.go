@@ -0,0 +1,108 @@
+package argocd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	argoprojlabsv1 "github.com/argoproj-labs/argo-cd-tokens/api/v1"
+)
+
+// appProjectObject returns an AppProject object with a role holding tokens issued at iats
+func appProjectObject(name string, role string, iats ...int64) *unstructured.Unstructured {
+	tokens := make([]interface{}, 0, len(iats))
+	for _, iat := range iats {
+		tokens = append(tokens, map[string]interface{}{"iat": iat})
+	}
+
+	object := NewAppProjectObject()
+	object.SetName(name)
+	object.SetNamespace("argocd")
+	object.Object["spec"] = map[string]interface{}{
+		"roles": []interface{}{map[string]interface{}{"name": role, "jwtTokens": tokens}},
+	}
+	return object
+}
+
+// countingClient counts the project fetches that reach Argo CD
+type countingClient struct {
+	ArgoCDAPI
+	gets int
+}
+
+func (c *countingClient) GetProject(ctx context.Context) (AppProject, error) {
+	c.gets++
+	return AppProject{}, nil
+}
+
+func (c *countingClient) GenerateToken(ctx context.Context, project AppProject) (string, error) {
+	return "tkn", nil
+}
+
+func TestClusterProjects(t *testing.T) {
+	ctx := context.Background()
+	reader := fakeclient.NewFakeClientWithScheme(scheme.Scheme, []runtime.Object{appProjectObject("default", "ci", 100)}...)
+	projects := NewClusterProjects(reader, "argocd", "https://argocd.example.com")
+
+	assert.True(t, projects.Serves("https://argocd.example.com/"))
+	assert.False(t, projects.Serves("https://other.example.com"))
+	var none *ClusterProjects
+	assert.False(t, none.Serves("https://argocd.example.com"))
+	// without its endpoint no Token is served, it can't tell which Argo CD they use
+	assert.False(t, NewClusterProjects(reader, "argocd", "").Serves("https://argocd.example.com"))
+
+	var token argoprojlabsv1.Token
+	token.Spec.Project = "default"
+	token.Spec.Role = "ci"
+	api := &countingClient{}
+	argoCDClient := projects.Client(api, token)
+
+	project, err := argoCDClient.GetProject(ctx)
+	assert.Nil(t, err)
+	assert.True(t, RoleExists("ci", project))
+	assert.False(t, TokenRevoked("ci", 100, project))
+	assert.True(t, TokenRevoked("ci", 200, project))
+	assert.False(t, TokenRevoked("admin", 200, project))
+	tokens, err := argoCDClient.ListTokens(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, []JWTToken{{IssuedAt: 100}}, tokens)
+	assert.Equal(t, 0, api.gets)
+
+	// until the change shows up in the cluster, the project is fetched from Argo CD
+	_, err = argoCDClient.GenerateToken(ctx, project)
+	assert.Nil(t, err)
+	_, err = argoCDClient.GetProject(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, api.gets)
+
+	updated := appProjectObject("default", "ci", 100, 200)
+	updated.SetResourceVersion("2")
+	assert.Nil(t, reader.Update(ctx, updated))
+	project, err = argoCDClient.GetProject(ctx)
+	assert.Nil(t, err)
+	assert.False(t, TokenRevoked("ci", 200, project))
+	assert.Equal(t, 1, api.gets)
+
+	token.Spec.Project = "missing"
+	_, err = projects.Client(api, token).GetProject(ctx)
+	assert.NotNil(t, err)
+}
+
+func TestRolesChanged(t *testing.T) {
+	project := func(object *unstructured.Unstructured) AppProject {
+		converted, err := ProjectFromObject(object)
+		assert.Nil(t, err)
+		return converted
+	}
+
+	base := project(appProjectObject("default", "ci", 100))
+	assert.False(t, RolesChanged(base, project(appProjectObject("default", "ci", 100))))
+	assert.True(t, RolesChanged(base, project(appProjectObject("default", "ci", 100, 200))))
+	assert.True(t, RolesChanged(base, project(appProjectObject("default", "ci"))))
+	assert.True(t, RolesChanged(base, project(appProjectObject("default", "deploy", 100))))
+}
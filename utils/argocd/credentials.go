@@ -0,0 +1,35 @@
+package argocd
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	argoprojlabsv1 "github.com/argoproj-labs/argo-cd-tokens/api/v1"
+)
+
+// CredentialsRefFunc loads the Argo CD token a Token's credentialsRef selects
+type CredentialsRefFunc func(ctx context.Context, token argoprojlabsv1.Token) (string, error)
+
+// SecretCredentialsRef loads credentialsRef from a Secret in the Token's namespace. The Secret is read on every
+// call so a rotated credential is picked up right away.
+func SecretCredentialsRef(reader client.Reader) CredentialsRefFunc {
+	return func(ctx context.Context, token argoprojlabsv1.Token) (string, error) {
+		ref := token.Spec.CredentialsRef
+		secretName := types.NamespacedName{Name: ref.Name, Namespace: token.Namespace}
+
+		var secret corev1.Secret
+		err := reader.Get(ctx, secretName, &secret)
+		if err != nil {
+			return "", err
+		}
+		credential := string(secret.Data[ref.Key])
+		if credential == "" {
+			return "", fmt.Errorf("Secret %s is missing %s", secretName, ref.Key)
+		}
+		return credential, nil
+	}
+}
@@ -0,0 +1,199 @@
+// Package fake provides an in-memory Argo CD for tests. It can be handed to the controller directly as an
+// argocd.ClientFactory, or served over Argo CD's REST API with NewServer. Either way it issues real signed JWTs.
+package fake
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	jwtgo "github.com/dgrijalva/jwt-go"
+
+	argoprojlabsv1 "github.com/argoproj-labs/argo-cd-tokens/api/v1"
+	"github.com/argoproj-labs/argo-cd-tokens/utils/argocd"
+)
+
+// ArgoCD holds projects in memory and issues tokens for their roles the way Argo CD does
+type ArgoCD struct {
+	// SigningKey signs the issued tokens
+	SigningKey []byte
+
+	mu       sync.Mutex
+	projects map[string]argocd.AppProject
+}
+
+var _ argocd.ClientFactory = &ArgoCD{}
+
+// NewArgoCD constructs an ArgoCD object holding the given projects
+func NewArgoCD(projects ...argocd.AppProject) *ArgoCD {
+	argoCD := &ArgoCD{
+		SigningKey: []byte("fake-argocd"),
+		projects:   make(map[string]argocd.AppProject),
+	}
+	for _, project := range projects {
+		argoCD.SetProject(project)
+	}
+	return argoCD
+}
+
+// NewProject returns a project with the given roles, each allowed to get the project's applications
+func NewProject(name string, roles ...string) argocd.AppProject {
+	var project argocd.AppProject
+	project.Name = name
+	for _, role := range roles {
+		project.Spec.Roles = append(project.Spec.Roles, argocd.ProjectRole{
+			Name:     role,
+			Policies: []string{fmt.Sprintf("p, proj:%s:%s, applications, get, %s/*, allow", name, role, name)},
+		})
+	}
+	return project
+}
+
+// SetProject creates or replaces a project
+func (f *ArgoCD) SetProject(project argocd.AppProject) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.projects[project.Name] = copyProject(project)
+}
+
+// Project returns a copy of a project, or false if it doesn't exist
+func (f *ArgoCD) Project(name string) (argocd.AppProject, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	project, ok := f.projects[name]
+	if !ok {
+		return argocd.AppProject{}, false
+	}
+	return copyProject(project), true
+}
+
+// Client returns an in-memory client for the Token, ignoring its endpoint and protocol
+func (f *ArgoCD) Client(ctx context.Context, token argoprojlabsv1.Token) (argocd.ArgoCDAPI, error) {
+	return &client{argoCD: f, token: token}, nil
+}
+
+func (f *ArgoCD) getProject(name string) (argocd.AppProject, error) {
+	project, ok := f.Project(name)
+	if !ok {
+		return project, fmt.Errorf("project %s not found", name)
+	}
+	return project, nil
+}
+
+func (f *ArgoCD) updateProject(project argocd.AppProject) (argocd.AppProject, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	current, ok := f.projects[project.Name]
+	if !ok {
+		return project, fmt.Errorf("project %s not found", project.Name)
+	}
+	if project.ResourceVersion != "" && project.ResourceVersion != current.ResourceVersion {
+		return project, fmt.Errorf("project %s was modified concurrently", project.Name)
+	}
+
+	current.Spec = copyProject(project).Spec
+	version, _ := strconv.Atoi(current.ResourceVersion)
+	current.ResourceVersion = strconv.Itoa(version + 1)
+	f.projects[project.Name] = current
+
+	return copyProject(current), nil
+}
+
+// createToken signs a token for the role. Argo CD identifies a role's tokens by their issued at time,
+// so a token issued within the same second as an existing one is dated a second later.
+func (f *ArgoCD) createToken(projectName string, roleName string, expiresIn int64) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	project, ok := f.projects[projectName]
+	if !ok {
+		return "", fmt.Errorf("project %s not found", projectName)
+	}
+	role := findRole(&project, roleName)
+	if role == nil {
+		return "", fmt.Errorf("role %s does not exist in project %s", roleName, projectName)
+	}
+
+	iat := time.Now().Unix()
+	for _, jwtToken := range role.JWTTokens {
+		if jwtToken.IssuedAt >= iat {
+			iat = jwtToken.IssuedAt + 1
+		}
+	}
+
+	claims := jwtgo.MapClaims{
+		"iat": iat,
+		"iss": "argocd",
+		"sub": fmt.Sprintf("proj:%s:%s", projectName, roleName),
+	}
+	jwtToken := argocd.JWTToken{IssuedAt: iat}
+	if expiresIn > 0 {
+		jwtToken.ExpiresAt = iat + expiresIn
+		claims["exp"] = jwtToken.ExpiresAt
+	}
+
+	tkn, err := jwtgo.NewWithClaims(jwtgo.SigningMethodHS256, claims).SignedString(f.SigningKey)
+	if err != nil {
+		return "", err
+	}
+
+	role.JWTTokens = append(role.JWTTokens, jwtToken)
+	f.projects[projectName] = project
+
+	return tkn, nil
+}
+
+// deleteToken removes a token from the role, succeeding like Argo CD does if there is no such token
+func (f *ArgoCD) deleteToken(projectName string, roleName string, iat int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	project, ok := f.projects[projectName]
+	if !ok {
+		return fmt.Errorf("project %s not found", projectName)
+	}
+	role := findRole(&project, roleName)
+	if role == nil {
+		return fmt.Errorf("role %s does not exist in project %s", roleName, projectName)
+	}
+
+	jwtTokens := make([]argocd.JWTToken, 0, len(role.JWTTokens))
+	for _, jwtToken := range role.JWTTokens {
+		if jwtToken.IssuedAt != iat {
+			jwtTokens = append(jwtTokens, jwtToken)
+		}
+	}
+	role.JWTTokens = jwtTokens
+	f.projects[projectName] = project
+
+	return nil
+}
+
+// findRole returns a pointer to the named role of the project, or nil
+func findRole(project *argocd.AppProject, roleName string) *argocd.ProjectRole {
+	for i := range project.Spec.Roles {
+		if project.Spec.Roles[i].Name == roleName {
+			return &project.Spec.Roles[i]
+		}
+	}
+	return nil
+}
+
+// copyProject deep copies a project so callers can't change what the fake holds
+func copyProject(project argocd.AppProject) argocd.AppProject {
+	var projectCopy argocd.AppProject
+	data, err := json.Marshal(project)
+	if err != nil {
+		panic(err)
+	}
+	if err := json.Unmarshal(data, &projectCopy); err != nil {
+		panic(err)
+	}
+	return projectCopy
+}
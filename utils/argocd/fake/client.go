@@ -0,0 +1,83 @@
+package fake
+
+import (
+	"context"
+	"fmt"
+
+	argoprojlabsv1 "github.com/argoproj-labs/argo-cd-tokens/api/v1"
+	"github.com/argoproj-labs/argo-cd-tokens/utils/argocd"
+	"github.com/argoproj-labs/argo-cd-tokens/utils/jwt"
+)
+
+// client is the in-memory argocd.ArgoCDAPI of one Token
+type client struct {
+	argoCD *ArgoCD
+	token  argoprojlabsv1.Token
+}
+
+func (c *client) GetProject(ctx context.Context) (argocd.AppProject, error) {
+	return c.argoCD.getProject(c.token.Spec.Project)
+}
+
+func (c *client) GenerateToken(ctx context.Context, project argocd.AppProject) (string, error) {
+	if findRole(&project, c.token.Spec.Role) == nil {
+		return "", fmt.Errorf("The role does not exist")
+	}
+	return c.argoCD.createToken(c.token.Spec.Project, c.token.Spec.Role, c.token.Spec.ExpiresInSeconds())
+}
+
+func (c *client) ListTokens(ctx context.Context) ([]argocd.JWTToken, error) {
+	project, err := c.argoCD.getProject(c.token.Spec.Project)
+	if err != nil {
+		return nil, err
+	}
+	role := findRole(&project, c.token.Spec.Role)
+	if role == nil {
+		return nil, fmt.Errorf("The role does not exist")
+	}
+	return role.JWTTokens, nil
+}
+
+func (c *client) DeleteToken(ctx context.Context, token string) error {
+	claims, err := jwt.ParseClaims(token)
+	if err != nil {
+		return err
+	}
+	return c.DeleteTokenIssuedAt(ctx, claims.IssuedAt)
+}
+
+func (c *client) DeleteTokenIssuedAt(ctx context.Context, iat int64) error {
+	return c.argoCD.deleteToken(c.token.Spec.Project, c.token.Spec.Role, iat)
+}
+
+func (c *client) CreateRole(ctx context.Context, role argocd.ProjectRole) error {
+	project, err := c.argoCD.getProject(c.token.Spec.Project)
+	if err != nil {
+		return err
+	}
+	if findRole(&project, role.Name) != nil {
+		return fmt.Errorf("The role %s already exists", role.Name)
+	}
+	project.Spec.Roles = append(project.Spec.Roles, role)
+	_, err = c.argoCD.updateProject(project)
+	return err
+}
+
+func (c *client) DeleteRole(ctx context.Context, name string) error {
+	project, err := c.argoCD.getProject(c.token.Spec.Project)
+	if err != nil {
+		return err
+	}
+	if findRole(&project, name) == nil {
+		return fmt.Errorf("The role %s does not exist", name)
+	}
+	roles := make([]argocd.ProjectRole, 0, len(project.Spec.Roles)-1)
+	for _, role := range project.Spec.Roles {
+		if role.Name != name {
+			roles = append(roles, role)
+		}
+	}
+	project.Spec.Roles = roles
+	_, err = c.argoCD.updateProject(project)
+	return err
+}
@@ -0,0 +1,97 @@
+package fake
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	argoprojlabsv1 "github.com/argoproj-labs/argo-cd-tokens/api/v1"
+	"github.com/argoproj-labs/argo-cd-tokens/utils/argocd"
+	"github.com/argoproj-labs/argo-cd-tokens/utils/jwt"
+)
+
+func newToken(endpoint string) argoprojlabsv1.Token {
+	var token argoprojlabsv1.Token
+	token.Spec.ArgoCDEndpt = endpoint
+	token.Spec.Project = "default"
+	token.Spec.Role = "ci"
+	expiresIn := 3600
+	token.Spec.ExpiresIn = &expiresIn
+	return token
+}
+
+func exerciseArgoCDAPI(t *testing.T, argoCD *ArgoCD, argoCDClient argocd.ArgoCDAPI) {
+	ctx := context.Background()
+
+	project, err := argoCDClient.GetProject(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "default", project.Name)
+
+	first, err := argoCDClient.GenerateToken(ctx, project)
+	assert.Nil(t, err)
+	firstClaims, err := jwt.ParseClaims(first)
+	assert.Nil(t, err)
+	assert.False(t, firstClaims.Expired(time.Now()))
+	assert.Nil(t, jwt.VerifyToken(first, "default", "ci", argoCD.SigningKey))
+
+	second, err := argoCDClient.GenerateToken(ctx, project)
+	assert.Nil(t, err)
+	secondClaims, err := jwt.ParseClaims(second)
+	assert.Nil(t, err)
+	assert.NotEqual(t, firstClaims.IssuedAt, secondClaims.IssuedAt)
+
+	tokens, err := argoCDClient.ListTokens(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(tokens))
+	assert.Equal(t, firstClaims.IssuedAt, tokens[0].IssuedAt)
+	assert.Equal(t, firstClaims.ExpiresAt, tokens[0].ExpiresAt)
+
+	assert.Nil(t, argoCDClient.DeleteToken(ctx, first))
+	assert.Nil(t, argoCDClient.DeleteTokenIssuedAt(ctx, secondClaims.IssuedAt))
+	tokens, err = argoCDClient.ListTokens(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(tokens))
+
+	assert.Nil(t, argoCDClient.CreateRole(ctx, argocd.ProjectRole{Name: "deploy"}))
+	assert.NotNil(t, argoCDClient.CreateRole(ctx, argocd.ProjectRole{Name: "deploy"}))
+	stored, _ := argoCD.Project("default")
+	assert.Equal(t, 2, len(stored.Spec.Roles))
+
+	assert.Nil(t, argoCDClient.DeleteRole(ctx, "deploy"))
+	assert.NotNil(t, argoCDClient.DeleteRole(ctx, "deploy"))
+	stored, _ = argoCD.Project("default")
+	assert.Equal(t, 1, len(stored.Spec.Roles))
+
+	_, err = argoCDClient.GenerateToken(ctx, NewProject("default"))
+	assert.NotNil(t, err)
+}
+
+func TestInMemoryArgoCD(t *testing.T) {
+	argoCD := NewArgoCD(NewProject("default", "ci"))
+
+	argoCDClient, err := argoCD.Client(context.Background(), newToken(""))
+	assert.Nil(t, err)
+	exerciseArgoCDAPI(t, argoCD, argoCDClient)
+}
+
+func TestServer(t *testing.T) {
+	argoCD := NewArgoCD(NewProject("default", "ci"))
+	server := NewServer(argoCD, "admin-token")
+	defer server.Close()
+	server.Users["admin"] = "password"
+
+	token := newToken(server.URL)
+	auth := argocd.NewSessionAuth(func(ctx context.Context) (argocd.Credentials, error) {
+		return argocd.Credentials{Username: "admin", Password: "password"}, nil
+	}, argocd.DefaultOptions(), "")
+	argoCDClient, err := argocd.NewArgoCDClient(auth, token, argocd.DefaultOptions(), nil)
+	assert.Nil(t, err)
+	exerciseArgoCDAPI(t, argoCD, &argoCDClient)
+
+	badClient, err := argocd.NewArgoCDClient(argocd.NewStaticAuth("wrong"), token, argocd.DefaultOptions(), nil)
+	assert.Nil(t, err)
+	_, err = badClient.GetProject(context.Background())
+	assert.True(t, argocd.IsUnauthorized(err))
+}
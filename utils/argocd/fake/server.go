@@ -0,0 +1,127 @@
+package fake
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+
+	"github.com/argoproj-labs/argo-cd-tokens/utils/argocd"
+)
+
+// Server serves an ArgoCD over the parts of Argo CD's REST API the controller uses
+type Server struct {
+	*httptest.Server
+	ArgoCD *ArgoCD
+	// AuthToken is the token callers must send in the argocd.token cookie, empty lets every caller in
+	AuthToken string
+	// Users maps the usernames that may log in through /api/v1/session to their passwords.
+	// A successful login returns AuthToken.
+	Users map[string]string
+}
+
+// NewServer starts a Server for the ArgoCD, it must be closed after use
+func NewServer(argoCD *ArgoCD, authTkn string) *Server {
+	s := &Server{
+		ArgoCD:    argoCD,
+		AuthToken: authTkn,
+		Users:     make(map[string]string),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	if r.Method == "POST" && r.URL.Path == "/api/v1/session" {
+		s.login(w, r)
+		return
+	}
+
+	if s.AuthToken != "" {
+		cookie, err := r.Cookie("argocd.token")
+		if err != nil || cookie.Value != s.AuthToken {
+			http.Error(w, "invalid session", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	if len(path) < 4 || path[0] != "api" || path[1] != "v1" || path[2] != "projects" {
+		http.NotFound(w, r)
+		return
+	}
+	projectName := path[3]
+
+	switch {
+	case len(path) == 4 && r.Method == "GET":
+		project, err := s.ArgoCD.getProject(projectName)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		writeJSON(w, project)
+
+	case len(path) == 4 && r.Method == "PUT":
+		var req argocd.ProjectUpdateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		req.Project.Name = projectName
+		project, err := s.ArgoCD.updateProject(req.Project)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		writeJSON(w, project)
+
+	case len(path) == 7 && path[4] == "roles" && path[6] == "token" && r.Method == "POST":
+		var req argocd.PostRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		tkn, err := s.ArgoCD.createToken(projectName, path[5], int64(req.ExpiresIn))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, argocd.Token{Token: tkn})
+
+	case len(path) == 8 && path[4] == "roles" && path[6] == "token" && r.Method == "DELETE":
+		iat, err := strconv.ParseInt(path[7], 10, 64)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := s.ArgoCD.deleteToken(projectName, path[5], iat); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, struct{}{})
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// login hands out AuthToken to known users
+func (s *Server) login(w http.ResponseWriter, r *http.Request) {
+	var creds argocd.Credentials
+	if err := json.NewDecoder(r.Body).Decode(&creds); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if password, ok := s.Users[creds.Username]; !ok || password != creds.Password {
+		http.Error(w, "invalid username or password", http.StatusUnauthorized)
+		return
+	}
+	writeJSON(w, argocd.Token{Token: s.AuthToken})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
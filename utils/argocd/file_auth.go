@@ -0,0 +1,107 @@
+package argocd
+
+import (
+	"context"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileAuth hands out the token stored in a file, typically a mounted Secret, and reloads it when the file changes
+type FileAuth struct {
+	path     string
+	interval time.Duration
+
+	mu        sync.RWMutex
+	token     string
+	listeners []func()
+}
+
+// NewFileAuth constructs a FileAuth object, failing if the file cannot be read
+func NewFileAuth(path string, interval time.Duration) (*FileAuth, error) {
+	f := &FileAuth{
+		path:     path,
+		interval: interval,
+	}
+
+	_, err := f.reload()
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Token returns the last token read from the file
+func (f *FileAuth) Token(ctx context.Context, endpoint string) (string, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.token, nil
+}
+
+// Invalidate re-reads the file right away in case the rejected token was already replaced
+func (f *FileAuth) Invalidate(endpoint string) bool {
+	return f.poll()
+}
+
+// OnChange registers a function that is called after a new token was loaded from the file
+func (f *FileAuth) OnChange(listener func()) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.listeners = append(f.listeners, listener)
+}
+
+// Start polls the file until stop is closed. It lets FileAuth run as a manager Runnable.
+func (f *FileAuth) Start(stop <-chan struct{}) error {
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+			f.poll()
+		}
+	}
+}
+
+// poll reloads the file and notifies the listeners if the token changed, which it reports
+func (f *FileAuth) poll() bool {
+	changed, err := f.reload()
+	if err != nil || !changed {
+		return false
+	}
+
+	f.mu.RLock()
+	listeners := f.listeners
+	f.mu.RUnlock()
+
+	for _, listener := range listeners {
+		listener()
+	}
+	return true
+}
+
+// reload reads the file and swaps in its token. Kubelet replaces mounted Secrets through an atomic symlink
+// swap, so a single read never sees a half written token.
+func (f *FileAuth) reload() (bool, error) {
+	content, err := ioutil.ReadFile(f.path)
+	if err != nil {
+		return false, err
+	}
+
+	tkn := strings.TrimSpace(string(content))
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if tkn == "" || tkn == f.token {
+		return false, nil
+	}
+	f.token = tkn
+	return true, nil
+}
@@ -0,0 +1,48 @@
+package argocd
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileAuth(t *testing.T) {
+	dir, err := ioutil.TempDir("", "file-auth")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "authTkn")
+	assert.Nil(t, ioutil.WriteFile(path, []byte("first\n"), 0600))
+
+	auth, err := NewFileAuth(path, 10*time.Millisecond)
+	assert.Nil(t, err)
+
+	tkn, err := auth.Token(context.Background(), "https://argocd.example.com")
+	assert.Nil(t, err)
+	assert.Equal(t, "first", tkn)
+
+	changed := make(chan struct{}, 1)
+	auth.OnChange(func() { changed <- struct{}{} })
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() { _ = auth.Start(stop) }()
+
+	assert.Nil(t, ioutil.WriteFile(path, []byte("second"), 0600))
+	select {
+	case <-changed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("token change was not detected")
+	}
+
+	tkn, _ = auth.Token(context.Background(), "https://argocd.example.com")
+	assert.Equal(t, "second", tkn)
+
+	_, err = NewFileAuth(filepath.Join(dir, "missing"), time.Second)
+	assert.NotNil(t, err)
+}
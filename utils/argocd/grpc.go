@@ -0,0 +1,273 @@
+package argocd
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/argoproj-labs/argo-cd-tokens/utils/argocd/project"
+)
+
+const (
+	// projectService is the gRPC service path of Argo CD's project API
+	projectService = "/project.ProjectService/"
+	// tokenMetadataKey is the gRPC metadata key Argo CD reads the auth token from
+	tokenMetadataKey = "token"
+)
+
+// errMalformedGRPCWeb is returned for a gRPC-web response whose frames are cut short
+var errMalformedGRPCWeb = errors.New("malformed gRPC-web response from Argo CD")
+
+// idempotentMethods are the project service methods that can safely be sent again after a transient failure.
+// Minting a token and updating the project are not, see isIdempotent.
+var idempotentMethods = map[string]bool{
+	"Get":         true,
+	"DeleteToken": true,
+}
+
+// invokeFunc sends one request to a project service method and decodes the response into resp
+type invokeFunc func(ctx context.Context, authTkn string, method string, req proto.Message, resp proto.Message) error
+
+// grpcProjectAPI talks to Argo CD's project service over gRPC or gRPC-web, depending on invoke, with the
+// messages generated from Argo CD's server/project/project.proto
+type grpcProjectAPI struct {
+	invoke invokeFunc
+	retry  retryPolicy
+}
+
+func (g *grpcProjectAPI) getProject(ctx context.Context, authTkn string, name string) (AppProject, error) {
+	var resp AppProject
+	err := g.call(ctx, authTkn, "Get", &project.ProjectQuery{Name: name}, &resp)
+	return resp, err
+}
+
+func (g *grpcProjectAPI) createToken(ctx context.Context, authTkn string, projectName string, role string, expiresIn int64) (string, error) {
+	req := &project.ProjectTokenCreateRequest{Project: projectName, Role: role, ExpiresIn: expiresIn}
+	var resp project.ProjectTokenResponse
+	err := g.call(ctx, authTkn, "CreateToken", req, &resp)
+	return resp.Token, err
+}
+
+func (g *grpcProjectAPI) deleteToken(ctx context.Context, authTkn string, projectName string, role string, iat int64) error {
+	req := &project.ProjectTokenDeleteRequest{Project: projectName, Role: role, Iat: iat}
+	return g.call(ctx, authTkn, "DeleteToken", req, &project.EmptyResponse{})
+}
+
+func (g *grpcProjectAPI) updateProject(ctx context.Context, authTkn string, appProject AppProject) (AppProject, error) {
+	var resp AppProject
+	err := g.call(ctx, authTkn, "Update", &project.ProjectUpdateRequest{Project: &appProject}, &resp)
+	return resp, err
+}
+
+// call invokes the method, retrying idempotent methods that failed transiently with the same policy as REST calls
+func (g *grpcProjectAPI) call(ctx context.Context, authTkn string, method string, req proto.Message, resp proto.Message) error {
+	for attempt := 0; ; attempt++ {
+		err := g.invoke(ctx, authTkn, method, req, resp)
+		if err == nil || !idempotentMethods[method] || !isTransient(err) || attempt >= g.retry.maxRetries {
+			return err
+		}
+		if err := g.retry.wait(ctx, attempt); err != nil {
+			return err
+		}
+	}
+}
+
+// grpcCallError is a project service call Argo CD answered with a failed gRPC status
+type grpcCallError struct {
+	method  string
+	code    codes.Code
+	message string
+}
+
+func (e *grpcCallError) Error() string {
+	return fmt.Sprintf("%s%s failed: %s (%s)", projectService, e.method, e.message, e.code)
+}
+
+// grpcError turns a failed gRPC status into the errors the rest of the client understands
+func grpcError(method string, code codes.Code, message string) error {
+	if code == codes.Unauthenticated {
+		return ErrUnauthorized
+	}
+	return &grpcCallError{method: method, code: code, message: message}
+}
+
+// transientCode reports whether a call failing with the code may succeed when sent again
+func transientCode(code codes.Code) bool {
+	switch code {
+	case codes.Unavailable, codes.Internal, codes.Unknown, codes.DeadlineExceeded:
+		return true
+	}
+	return false
+}
+
+// isTransient reports whether a failed call may succeed when sent again: it failed with a transient gRPC status or
+// didn't reach Argo CD at all
+func isTransient(err error) bool {
+	var callErr *grpcCallError
+	if errors.As(err, &callErr) {
+		return transientCode(callErr.code)
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// dialGRPC opens a native gRPC connection to the Argo CD API server
+func dialGRPC(endpoint string, tlsConfig *tls.Config) (*grpc.ClientConn, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	target := u.Host
+	if u.Port() == "" {
+		port := "443"
+		if u.Scheme == "http" {
+			port = "80"
+		}
+		target = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	dialOpt := grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig))
+	if u.Scheme == "http" {
+		dialOpt = grpc.WithInsecure()
+	}
+
+	return grpc.Dial(target, dialOpt)
+}
+
+// nativeGRPCInvoker calls the project service over a native gRPC connection, reporting to the circuit breaker. Each
+// call is bounded by requestTimeout, like the HTTP client bounds REST and gRPC-web calls.
+func nativeGRPCInvoker(conn *grpc.ClientConn, breakers *CircuitBreakers, endpoint string, requestTimeout time.Duration) invokeFunc {
+	host := hostOf(endpoint)
+
+	return func(ctx context.Context, authTkn string, method string, req proto.Message, resp proto.Message) error {
+		if breakers != nil && !breakers.allow(host) {
+			return ErrArgoCDUnavailable
+		}
+
+		if requestTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, requestTimeout)
+			defer cancel()
+		}
+		ctx = metadata.AppendToOutgoingContext(ctx, tokenMetadataKey, authTkn)
+
+		err := conn.Invoke(ctx, projectService+method, req, resp)
+		grpcStatus := status.Convert(err)
+
+		if breakers != nil {
+			breakers.record(host, !transientCode(grpcStatus.Code()))
+		}
+
+		if err != nil {
+			return grpcError(method, grpcStatus.Code(), grpcStatus.Message())
+		}
+		return nil
+	}
+}
+
+// grpcWebInvoker calls the project service with the gRPC-web protocol over the pooled HTTP client,
+// for Argo CD instances behind ingresses that only pass HTTP/1.1
+func grpcWebInvoker(client *http.Client, endpoint string) invokeFunc {
+	return func(ctx context.Context, authTkn string, method string, req proto.Message, resp proto.Message) error {
+		encoded, err := proto.Marshal(req)
+		if err != nil {
+			return err
+		}
+		frame := make([]byte, 5+len(encoded))
+		binary.BigEndian.PutUint32(frame[1:5], uint32(len(encoded)))
+		copy(frame[5:], encoded)
+
+		request, err := http.NewRequestWithContext(ctx, "POST", endpoint+projectService+method, bytes.NewReader(frame))
+		if err != nil {
+			return err
+		}
+		request.Header.Set("Content-Type", "application/grpc-web+proto")
+		request.Header.Set("X-Grpc-Web", "1")
+		request.Header.Set(tokenMetadataKey, authTkn)
+
+		response, err := client.Do(request)
+		if err != nil {
+			return err
+		}
+
+		defer response.Body.Close()
+
+		body, err := ioutil.ReadAll(response.Body)
+		if err != nil {
+			return err
+		}
+
+		if response.StatusCode == http.StatusUnauthorized {
+			return ErrUnauthorized
+		}
+		if response.StatusCode >= http.StatusInternalServerError {
+			return grpcError(method, codes.Unavailable, response.Status)
+		}
+		if response.StatusCode != http.StatusOK {
+			return fmt.Errorf("%s%s failed: %s", projectService, method, response.Status)
+		}
+
+		grpcStatus := response.Header.Get("Grpc-Status")
+		grpcMessage := response.Header.Get("Grpc-Message")
+
+		var msg []byte
+		for len(body) > 0 {
+			if len(body) < 5 {
+				return errMalformedGRPCWeb
+			}
+			flags := body[0]
+			length := binary.BigEndian.Uint32(body[1:5])
+			if uint64(len(body)-5) < uint64(length) {
+				return errMalformedGRPCWeb
+			}
+			payload := body[5 : 5+length]
+			body = body[5+length:]
+
+			if flags&0x80 == 0 {
+				msg = payload
+				continue
+			}
+			// trailers are sent as a final frame of HTTP/1 style headers
+			for _, line := range strings.Split(string(payload), "\r\n") {
+				parts := strings.SplitN(line, ":", 2)
+				if len(parts) != 2 {
+					continue
+				}
+				switch strings.ToLower(strings.TrimSpace(parts[0])) {
+				case "grpc-status":
+					grpcStatus = strings.TrimSpace(parts[1])
+				case "grpc-message":
+					grpcMessage = strings.TrimSpace(parts[1])
+				}
+			}
+		}
+
+		if grpcStatus != "" && grpcStatus != "0" {
+			code, err := strconv.Atoi(grpcStatus)
+			if err != nil {
+				return grpcError(method, codes.Unknown, grpcMessage)
+			}
+			return grpcError(method, codes.Code(code), grpcMessage)
+		}
+
+		return proto.Unmarshal(msg, resp)
+	}
+}
@@ -0,0 +1,238 @@
+package argocd
+
+import (
+	"context"
+	"encoding/binary"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	argoprojlabsv1 "github.com/argoproj-labs/argo-cd-tokens/api/v1"
+	"github.com/argoproj-labs/argo-cd-tokens/utils/argocd/project"
+)
+
+// fakeProjectServer answers project service calls with Argo CD's generated messages. The first failGets calls to
+// Get and the first failCreates calls to CreateToken fail with codes.Unavailable. Calls to Get hang until stall is
+// closed, if it is set.
+type fakeProjectServer struct {
+	project.ProjectServiceServer
+	t     *testing.T
+	stall chan struct{}
+
+	mu          sync.Mutex
+	failGets    int
+	failCreates int
+	gets        int
+	creates     int
+}
+
+func (f *fakeProjectServer) authorize(ctx context.Context) error {
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md[tokenMetadataKey]) > 0 && strings.HasPrefix(md[tokenMetadataKey][0], "tkn") {
+		return nil
+	}
+	return status.Error(codes.Unauthenticated, "rejected")
+}
+
+func (f *fakeProjectServer) Get(ctx context.Context, q *project.ProjectQuery) (*AppProject, error) {
+	if err := f.authorize(ctx); err != nil {
+		return nil, err
+	}
+	if f.stall != nil {
+		<-f.stall
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.gets++
+	if f.gets <= f.failGets {
+		return nil, status.Error(codes.Unavailable, "restarting")
+	}
+
+	assert.Equal(f.t, "default", q.Name)
+	return &AppProject{
+		ObjectMeta: metav1.ObjectMeta{Name: "default"},
+		Spec: AppProjectSpec{
+			Description: "default project",
+			Roles: []ProjectRole{{
+				Name:      "ci",
+				Policies:  []string{"p, proj:default:ci, applications, get, default/*, allow"},
+				JWTTokens: []JWTToken{{IssuedAt: 1565022426}},
+			}},
+		},
+	}, nil
+}
+
+func (f *fakeProjectServer) CreateToken(ctx context.Context, req *project.ProjectTokenCreateRequest) (*project.ProjectTokenResponse, error) {
+	if err := f.authorize(ctx); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.creates++
+	if f.creates <= f.failCreates {
+		return nil, status.Error(codes.Unavailable, "restarting")
+	}
+
+	assert.Equal(f.t, "default", req.Project)
+	assert.Equal(f.t, "ci", req.Role)
+	assert.Equal(f.t, int64(3600), req.ExpiresIn)
+	return &project.ProjectTokenResponse{Token: signedToken(f.t, time.Hour)}, nil
+}
+
+func (f *fakeProjectServer) DeleteToken(ctx context.Context, req *project.ProjectTokenDeleteRequest) (*project.EmptyResponse, error) {
+	if err := f.authorize(ctx); err != nil {
+		return nil, err
+	}
+	assert.Equal(f.t, "ci", req.Role)
+	return &project.EmptyResponse{}, nil
+}
+
+// serve calls the server's method with the encoded request, the way the generated gRPC handlers do
+func (f *fakeProjectServer) serve(ctx context.Context, method string, req []byte) (proto.Message, error) {
+	switch method {
+	case projectService + "Get":
+		var q project.ProjectQuery
+		assert.Nil(f.t, proto.Unmarshal(req, &q))
+		return f.Get(ctx, &q)
+	case projectService + "CreateToken":
+		var create project.ProjectTokenCreateRequest
+		assert.Nil(f.t, proto.Unmarshal(req, &create))
+		return f.CreateToken(ctx, &create)
+	case projectService + "DeleteToken":
+		var del project.ProjectTokenDeleteRequest
+		assert.Nil(f.t, proto.Unmarshal(req, &del))
+		return f.DeleteToken(ctx, &del)
+	}
+	return nil, status.Error(codes.Unimplemented, method)
+}
+
+func exerciseProjectService(t *testing.T, token argoprojlabsv1.Token, server *fakeProjectServer) {
+	ctx := context.Background()
+	token.Spec.Project = "default"
+	token.Spec.Role = "ci"
+	expiresIn := 3600
+	token.Spec.ExpiresIn = &expiresIn
+
+	opts := DefaultOptions()
+	opts.RetryBaseDelay = time.Millisecond
+	argoCDClient, err := NewArgoCDClient(NewStaticAuth("tkn"), token, opts, nil)
+	assert.Nil(t, err)
+
+	// reading the project is retried when Argo CD is briefly unavailable
+	server.failGets = 1
+	project, err := argoCDClient.GetProject(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, server.gets)
+	assert.Equal(t, "default", project.Name)
+	assert.Equal(t, "default project", project.Spec.Description)
+	assert.Equal(t, 1, len(project.Spec.Roles))
+	assert.Equal(t, "ci", project.Spec.Roles[0].Name)
+	assert.Equal(t, int64(1565022426), project.Spec.Roles[0].JWTTokens[0].IssuedAt)
+
+	// minting is not, it could leave an extra token behind
+	server.failCreates = 1
+	_, err = argoCDClient.GenerateToken(ctx, project)
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, server.creates)
+
+	tkn, err := argoCDClient.GenerateToken(ctx, project)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, tkn)
+
+	assert.Nil(t, argoCDClient.DeleteToken(ctx, tkn))
+
+	badClient, err := NewArgoCDClient(NewStaticAuth("wrong"), token, opts, nil)
+	assert.Nil(t, err)
+	_, err = badClient.GetProject(ctx)
+	assert.True(t, IsUnauthorized(err))
+}
+
+func TestGRPCProjectAPI(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+
+	fakeServer := &fakeProjectServer{t: t}
+	server := grpc.NewServer()
+	project.RegisterProjectServiceServer(server, fakeServer)
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+
+	var token argoprojlabsv1.Token
+	token.Spec.ArgoCDEndpt = "http://" + listener.Addr().String()
+	token.Spec.Protocol = argoprojlabsv1.ProtocolGRPC
+	exerciseProjectService(t, token, fakeServer)
+}
+
+func TestGRPCRequestTimeout(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+
+	fakeServer := &fakeProjectServer{t: t, stall: make(chan struct{})}
+	server := grpc.NewServer()
+	project.RegisterProjectServiceServer(server, fakeServer)
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+	defer close(fakeServer.stall)
+
+	var token argoprojlabsv1.Token
+	token.Spec.ArgoCDEndpt = "http://" + listener.Addr().String()
+	token.Spec.Project = "default"
+	token.Spec.Protocol = argoprojlabsv1.ProtocolGRPC
+	opts := DefaultOptions()
+	opts.RequestTimeout = 50 * time.Millisecond
+	opts.MaxRetries = 0
+	argoCDClient, err := NewArgoCDClient(NewStaticAuth("tkn"), token, opts, nil)
+	assert.Nil(t, err)
+
+	// a hung call gives up after the request timeout, not only when the reconcile's deadline passes
+	start := time.Now()
+	_, err = argoCDClient.GetProject(context.Background())
+	assert.NotNil(t, err)
+	assert.True(t, time.Since(start) < time.Second)
+}
+
+func TestGRPCWebProjectAPI(t *testing.T) {
+	fakeServer := &fakeProjectServer{t: t}
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/grpc-web+proto", r.Header.Get("Content-Type"))
+		body, _ := ioutil.ReadAll(r.Body)
+		ctx := metadata.NewIncomingContext(r.Context(), metadata.Pairs(tokenMetadataKey, r.Header.Get(tokenMetadataKey)))
+		resp, err := fakeServer.serve(ctx, r.URL.Path, body[5:])
+
+		w.Header().Set("Content-Type", "application/grpc-web+proto")
+		if err == nil {
+			encoded, err := proto.Marshal(resp)
+			assert.Nil(t, err)
+			frame := make([]byte, 5+len(encoded))
+			binary.BigEndian.PutUint32(frame[1:5], uint32(len(encoded)))
+			copy(frame[5:], encoded)
+			_, _ = w.Write(frame)
+		}
+		grpcStatus := status.Convert(err)
+		trailer := []byte("grpc-status: " + strconv.Itoa(int(grpcStatus.Code())) + "\r\ngrpc-message: " + grpcStatus.Message() + "\r\n")
+		trailerFrame := make([]byte, 5+len(trailer))
+		trailerFrame[0] = 0x80
+		binary.BigEndian.PutUint32(trailerFrame[1:5], uint32(len(trailer)))
+		copy(trailerFrame[5:], trailer)
+		_, _ = w.Write(trailerFrame)
+	}))
+	defer server.Close()
+
+	var token argoprojlabsv1.Token
+	token.Spec.ArgoCDEndpt = server.URL
+	token.Spec.Protocol = argoprojlabsv1.ProtocolGRPCWeb
+	exerciseProjectService(t, token, fakeServer)
+}
@@ -0,0 +1,130 @@
+package argocd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+// restAPI talks to Argo CD through its REST gateway
+type restAPI struct {
+	client   *http.Client
+	endpoint string
+}
+
+func (r *restAPI) getProject(ctx context.Context, authTkn string, name string) (AppProject, error) {
+
+	argoCDEndpt := fmt.Sprintf("%s/api/v1/projects/%s", r.endpoint, name)
+
+	var project AppProject
+
+	body, err := r.do(ctx, authTkn, "GET", argoCDEndpt, nil)
+	if err != nil {
+		return project, err
+	}
+
+	err = json.Unmarshal(body, &project)
+	if err != nil {
+		return project, err
+	}
+
+	return project, nil
+}
+
+func (r *restAPI) createToken(ctx context.Context, authTkn string, project string, role string, expiresIn int64) (string, error) {
+
+	argoCDEndpt := fmt.Sprintf("%s/api/v1/projects/%s/roles/%s/token", r.endpoint, project, role)
+
+	postReq := PostRequest{
+		ExpiresIn: int(expiresIn),
+		Project:   project,
+		Role:      role,
+	}
+
+	bytePostReq, err := json.Marshal(postReq)
+	if err != nil {
+		return "", err
+	}
+
+	body, err := r.do(ctx, authTkn, "POST", argoCDEndpt, bytePostReq)
+	if err != nil {
+		return "", err
+	}
+
+	var tkn Token
+	err = json.Unmarshal(body, &tkn)
+	if err != nil {
+		return "", err
+	}
+
+	return tkn.Token, nil
+}
+
+func (r *restAPI) deleteToken(ctx context.Context, authTkn string, project string, role string, iat int64) error {
+
+	argoCDEndpt := fmt.Sprintf("%s/api/v1/projects/%s/roles/%s/token/%d", r.endpoint, project, role, iat)
+
+	_, err := r.do(ctx, authTkn, "DELETE", argoCDEndpt, nil)
+	return err
+}
+
+func (r *restAPI) updateProject(ctx context.Context, authTkn string, project AppProject) (AppProject, error) {
+
+	argoCDEndpt := fmt.Sprintf("%s/api/v1/projects/%s", r.endpoint, project.Name)
+
+	var updated AppProject
+
+	payload, err := json.Marshal(ProjectUpdateRequest{Project: project})
+	if err != nil {
+		return updated, err
+	}
+
+	body, err := r.do(ctx, authTkn, "PUT", argoCDEndpt, payload)
+	if err != nil {
+		return updated, err
+	}
+
+	err = json.Unmarshal(body, &updated)
+	if err != nil {
+		return updated, err
+	}
+
+	return updated, nil
+}
+
+// do sends a request to Argo CD and returns the response body
+func (r *restAPI) do(ctx context.Context, authTkn string, method string, argoCDEndpt string, payload []byte) ([]byte, error) {
+
+	request, err := http.NewRequestWithContext(ctx, method, argoCDEndpt, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	if payload != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	request.AddCookie(&http.Cookie{Name: "argocd.token", Value: authTkn})
+
+	response, err := r.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode == http.StatusUnauthorized {
+		return nil, ErrUnauthorized
+	}
+	if response.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("%s %s failed: %s", method, argoCDEndpt, response.Status)
+	}
+
+	return body, nil
+}
@@ -0,0 +1,198 @@
+package argocd
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// ErrArgoCDUnavailable is returned without calling Argo CD while its circuit breaker is open
+var ErrArgoCDUnavailable = errors.New("Argo CD is unavailable, calls are paused until it recovers")
+
+// IsUnavailable reports whether a Client call failed because the circuit breaker for Argo CD is open
+func IsUnavailable(err error) bool {
+	return errors.Is(err, ErrArgoCDUnavailable)
+}
+
+// CircuitBreakers tracks consecutive failures per Argo CD endpoint. After Threshold failures in a row the
+// endpoint's breaker opens and calls fail fast for Cooldown, after which a single probe call is let through.
+type CircuitBreakers struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	breakers map[string]*breaker
+}
+
+type breaker struct {
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+// NewCircuitBreakers constructs a CircuitBreakers object
+func NewCircuitBreakers(threshold int, cooldown time.Duration) *CircuitBreakers {
+	return &CircuitBreakers{
+		threshold: threshold,
+		cooldown:  cooldown,
+		breakers:  make(map[string]*breaker),
+	}
+}
+
+// OpenFor returns how long calls to the endpoint will keep failing fast, or 0 if they are let through
+func (c *CircuitBreakers) OpenFor(endpoint string) time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	b, ok := c.breakers[hostOf(endpoint)]
+	if !ok {
+		return 0
+	}
+	openFor := time.Until(b.openUntil)
+	if openFor < 0 {
+		return 0
+	}
+	return openFor
+}
+
+// allow reports whether a call to the host may be made. Once the cooldown has passed only one probe is let
+// through until its result is recorded.
+func (c *CircuitBreakers) allow(host string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	b, ok := c.breakers[host]
+	if !ok || b.failures < c.threshold {
+		return true
+	}
+	if time.Now().Before(b.openUntil) || b.probing {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// record stores the outcome of a call to the host
+func (c *CircuitBreakers) record(host string, success bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if success {
+		delete(c.breakers, host)
+		return
+	}
+
+	b, ok := c.breakers[host]
+	if !ok {
+		b = &breaker{}
+		c.breakers[host] = b
+	}
+	b.failures++
+	b.probing = false
+	if b.failures >= c.threshold {
+		b.openUntil = time.Now().Add(c.cooldown)
+	}
+}
+
+// retryPolicy is how often and after which backoff idempotent calls to Argo CD are retried
+type retryPolicy struct {
+	maxRetries int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+}
+
+// newRetryPolicy returns the retry policy of the Options
+func newRetryPolicy(opts Options) retryPolicy {
+	return retryPolicy{
+		maxRetries: opts.MaxRetries,
+		baseDelay:  opts.RetryBaseDelay,
+		maxDelay:   opts.RetryMaxDelay,
+	}
+}
+
+// backoff returns a random delay of up to baseDelay * 2^attempt, capped at maxDelay
+func (p retryPolicy) backoff(attempt int) time.Duration {
+	delay := p.baseDelay << uint(attempt)
+	if delay <= 0 || delay > p.maxDelay {
+		delay = p.maxDelay
+	}
+	return time.Duration(rand.Int63n(int64(delay) + 1))
+}
+
+// wait sleeps for the backoff of the attempt, returning early with an error once ctx is done
+func (p retryPolicy) wait(ctx context.Context, attempt int) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(p.backoff(attempt)):
+		return nil
+	}
+}
+
+// retryTransport retries idempotent calls that failed with a connection error or a 5xx response using
+// jittered exponential backoff, and reports every outcome to the circuit breaker of the endpoint
+type retryTransport struct {
+	retryPolicy
+	next     http.RoundTripper
+	breakers *CircuitBreakers
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *retryTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	host := request.URL.Host
+
+	for attempt := 0; ; attempt++ {
+		if t.breakers != nil && !t.breakers.allow(host) {
+			return nil, ErrArgoCDUnavailable
+		}
+
+		if attempt > 0 && request.GetBody != nil {
+			body, err := request.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			request.Body = body
+		}
+
+		response, err := t.next.RoundTrip(request)
+		failed := err != nil || response.StatusCode >= http.StatusInternalServerError
+		if t.breakers != nil {
+			t.breakers.record(host, !failed)
+		}
+
+		if !failed || !isIdempotent(request.Method) || attempt >= t.maxRetries {
+			return response, err
+		}
+
+		if response != nil {
+			response.Body.Close()
+		}
+
+		if err := t.wait(request.Context(), attempt); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// isIdempotent reports whether a call can safely be sent again. Minting a token is a POST and never retried,
+// since a retry could leave an extra token behind in Argo CD.
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// hostOf returns the host of an endpoint URL, which is what circuit breakers are keyed by
+func hostOf(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return endpoint
+	}
+	return u.Host
+}
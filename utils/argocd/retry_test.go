@@ -0,0 +1,66 @@
+package argocd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	argoprojlabsv1 "github.com/argoproj-labs/argo-cd-tokens/api/v1"
+)
+
+func TestRetryAndCircuitBreaker(t *testing.T) {
+	calls := 0
+	failures := 0
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(AppProject{Spec: AppProjectSpec{Roles: []ProjectRole{{Name: "ci"}}}})
+	}))
+	defer server.Close()
+
+	var token argoprojlabsv1.Token
+	token.Spec.ArgoCDEndpt = server.URL
+	token.Spec.Project = "default"
+	token.Spec.Role = "ci"
+
+	opts := Options{MaxRetries: 3, RetryBaseDelay: time.Millisecond, RetryMaxDelay: 5 * time.Millisecond}
+	breakers := NewCircuitBreakers(3, time.Hour)
+	argoCDClient, err := NewArgoCDClient(NewStaticAuth("tkn"), token, opts, breakers)
+	assert.Nil(t, err)
+	ctx := context.Background()
+
+	// idempotent calls are retried through transient failures
+	failures = 2
+	project, err := argoCDClient.GetProject(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, time.Duration(0), breakers.OpenFor(server.URL))
+
+	// minting a token is never retried
+	calls = 0
+	failures = 1
+	_, err = argoCDClient.GenerateToken(ctx, project)
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, calls)
+
+	// enough consecutive failures open the breaker and further calls fail fast
+	calls = 0
+	failures = 100
+	_, err = argoCDClient.GetProject(ctx)
+	assert.True(t, IsUnavailable(err))
+	assert.Equal(t, 2, calls)
+	assert.True(t, breakers.OpenFor(server.URL) > 0)
+
+	_, err = argoCDClient.GetProject(ctx)
+	assert.True(t, IsUnavailable(err))
+	assert.Equal(t, 2, calls)
+}
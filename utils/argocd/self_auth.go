@@ -0,0 +1,140 @@
+package argocd
+
+import (
+	"context"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	argoprojlabsv1 "github.com/argoproj-labs/argo-cd-tokens/api/v1"
+	argoprojlabsv2 "github.com/argoproj-labs/argo-cd-tokens/api/v2"
+	"github.com/argoproj-labs/argo-cd-tokens/utils/jwt"
+)
+
+// SelfTokenFunc returns the endpoint and current value of the controller's own credential.
+// An empty token means the credential has not been minted yet.
+type SelfTokenFunc func(ctx context.Context) (endpoint string, token string, err error)
+
+// SelfTokenSource reads the controller's own credential from the Secret of the given Token, with the controller's
+// defaults merged under its spec like the reconciler does. The Token is read in v2, the version the controller
+// watches Tokens in.
+func SelfTokenSource(reader client.Reader, tokenName types.NamespacedName, defaults argoprojlabsv1.TokenSettings) SelfTokenFunc {
+	return func(ctx context.Context) (string, string, error) {
+		var stored argoprojlabsv2.Token
+		err := reader.Get(ctx, tokenName, &stored)
+		if err != nil {
+			return "", "", err
+		}
+		var token argoprojlabsv1.Token
+		if err := token.ConvertFrom(&stored); err != nil {
+			return "", "", err
+		}
+		defaults.Apply(&token.Spec)
+
+		secretName := types.NamespacedName{
+			Name:      token.Spec.SecretRef.Name,
+			Namespace: token.Namespace,
+		}
+
+		var secret corev1.Secret
+		err = reader.Get(ctx, secretName, &secret)
+		if apierrors.IsNotFound(err) {
+			return token.Spec.ArgoCDEndpt, "", nil
+		}
+		if err != nil {
+			return "", "", err
+		}
+
+		return token.Spec.ArgoCDEndpt, string(secret.Data[token.Spec.SecretRef.Key]), nil
+	}
+}
+
+// SelfManagedAuth authenticates with a credential the controller mints and rotates for itself.
+// Until that credential exists, or whenever it is expired or rejected by Argo CD, it falls back to
+// the bootstrap provider so the controller can always mint a replacement.
+type SelfManagedAuth struct {
+	// Expiry decides when the credential has expired, by default on the real clock without skew
+	Expiry jwt.Expiry
+
+	self      SelfTokenFunc
+	bootstrap AuthProvider
+
+	mu        sync.Mutex
+	rejected  string
+	listeners []func()
+}
+
+// NewSelfManagedAuth constructs a SelfManagedAuth object
+func NewSelfManagedAuth(self SelfTokenFunc, bootstrap AuthProvider) *SelfManagedAuth {
+	return &SelfManagedAuth{
+		self:      self,
+		bootstrap: bootstrap,
+	}
+}
+
+// Token returns the self-managed credential when it is usable for the endpoint, else the bootstrap token
+func (s *SelfManagedAuth) Token(ctx context.Context, endpoint string) (string, error) {
+	if tkn, ok := s.usableSelfToken(ctx, endpoint); ok {
+		return tkn, nil
+	}
+
+	return s.bootstrap.Token(ctx, endpoint)
+}
+
+// Invalidate stops using the self-managed credential until it is rotated, or drops the bootstrap session
+// when that was the token Argo CD rejected
+func (s *SelfManagedAuth) Invalidate(endpoint string) bool {
+	if tkn, ok := s.usableSelfToken(context.Background(), endpoint); ok {
+		s.mu.Lock()
+		s.rejected = tkn
+		listeners := s.listeners
+		s.mu.Unlock()
+
+		for _, listener := range listeners {
+			listener()
+		}
+		// the bootstrap token is tried next
+		return true
+	}
+
+	return s.bootstrap.Invalidate(endpoint)
+}
+
+// Rejected reports whether Argo CD rejected tkn as the controller's own credential
+func (s *SelfManagedAuth) Rejected(tkn string) bool {
+	return tkn != "" && s.isRejected(tkn)
+}
+
+// OnReject registers a function that is called when Argo CD rejected the controller's own credential, so it can be
+// replaced right away instead of when it expires
+func (s *SelfManagedAuth) OnReject(listener func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.listeners = append(s.listeners, listener)
+}
+
+// usableSelfToken returns the self-managed credential if it belongs to the endpoint and is neither expired nor rejected
+func (s *SelfManagedAuth) usableSelfToken(ctx context.Context, endpoint string) (string, bool) {
+	selfEndpt, tkn, err := s.self(ctx)
+	if err != nil || selfEndpt != endpoint || tkn == "" || s.isRejected(tkn) {
+		return "", false
+	}
+
+	claims, err := jwt.ParseClaims(tkn)
+	if err != nil || s.Expiry.Expired(claims) {
+		return "", false
+	}
+
+	return tkn, true
+}
+
+func (s *SelfManagedAuth) isRejected(tkn string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.rejected == tkn
+}
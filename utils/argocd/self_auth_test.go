@@ -0,0 +1,179 @@
+package argocd
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	jwtgo "github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	argoprojlabsv1 "github.com/argoproj-labs/argo-cd-tokens/api/v1"
+	argoprojlabsv2 "github.com/argoproj-labs/argo-cd-tokens/api/v2"
+	"github.com/argoproj-labs/argo-cd-tokens/utils/jwt"
+)
+
+// countingAuth is a bootstrap provider that records invalidations
+type countingAuth struct {
+	token       string
+	invalidated int
+}
+
+func (c *countingAuth) Token(ctx context.Context, endpoint string) (string, error) {
+	return c.token, nil
+}
+
+func (c *countingAuth) Invalidate(endpoint string) bool {
+	c.invalidated++
+	return true
+}
+
+func signedToken(t *testing.T, expiresIn time.Duration) string {
+	now := time.Now()
+	tkn, err := jwtgo.NewWithClaims(jwtgo.SigningMethodHS256, jwtgo.MapClaims{
+		"iat": now.Unix(),
+		"exp": now.Add(expiresIn).Unix(),
+		"iss": "argocd",
+		"sub": "proj:token-controller:controller",
+	}).SignedString([]byte("secret"))
+	assert.Nil(t, err)
+	return tkn
+}
+
+func TestSelfManagedAuth(t *testing.T) {
+	endpoint := "https://argocd.example.com"
+	validTkn := signedToken(t, time.Hour)
+	expiredTkn := signedToken(t, -time.Hour)
+
+	bootstrap := &countingAuth{token: "bootstrap"}
+	selfTkn := ""
+	var selfErr error
+	ctx := context.Background()
+	auth := NewSelfManagedAuth(func(ctx context.Context) (string, string, error) {
+		return endpoint, selfTkn, selfErr
+	}, bootstrap)
+
+	// nothing minted yet, so the bootstrap token is used to mint the first credential
+	tkn, err := auth.Token(ctx, endpoint)
+	assert.Nil(t, err)
+	assert.Equal(t, "bootstrap", tkn)
+
+	// once minted the controller's own credential takes over
+	selfTkn = validTkn
+	tkn, _ = auth.Token(ctx, endpoint)
+	assert.Equal(t, validTkn, tkn)
+
+	// other Argo CD instances keep using the bootstrap token
+	tkn, _ = auth.Token(ctx, "https://other.example.com")
+	assert.Equal(t, "bootstrap", tkn)
+
+	// a revoked credential falls back to the bootstrap token until it is rotated
+	auth.Invalidate(endpoint)
+	assert.Equal(t, 0, bootstrap.invalidated)
+	tkn, _ = auth.Token(ctx, endpoint)
+	assert.Equal(t, "bootstrap", tkn)
+
+	// rejections of the bootstrap token are passed on
+	auth.Invalidate(endpoint)
+	assert.Equal(t, 1, bootstrap.invalidated)
+
+	selfTkn = signedToken(t, 2*time.Hour)
+	tkn, _ = auth.Token(ctx, endpoint)
+	assert.Equal(t, selfTkn, tkn)
+
+	// an expired credential or an unreadable Secret recovers through the bootstrap token
+	selfTkn = expiredTkn
+	tkn, _ = auth.Token(ctx, endpoint)
+	assert.Equal(t, "bootstrap", tkn)
+
+	selfTkn = validTkn
+	selfErr = errors.New("secret not readable")
+	tkn, _ = auth.Token(ctx, endpoint)
+	assert.Equal(t, "bootstrap", tkn)
+}
+
+func TestSelfManagedAuthExpiry(t *testing.T) {
+	endpoint := "https://argocd.example.com"
+	selfTkn := signedToken(t, time.Hour)
+
+	ctx := context.Background()
+	auth := NewSelfManagedAuth(func(ctx context.Context) (string, string, error) {
+		return endpoint, selfTkn, nil
+	}, &countingAuth{token: "bootstrap"})
+	fakeClock := clock.NewFakeClock(time.Now())
+	auth.Expiry = jwt.Expiry{Clock: fakeClock, Skew: time.Minute}
+
+	tkn, _ := auth.Token(ctx, endpoint)
+	assert.Equal(t, selfTkn, tkn)
+
+	// the credential counts as expired on the injected clock, allowing for the skew
+	fakeClock.Step(time.Hour - 30*time.Second)
+	tkn, _ = auth.Token(ctx, endpoint)
+	assert.Equal(t, "bootstrap", tkn)
+}
+
+func TestSelfManagedAuthOnReject(t *testing.T) {
+	endpoint := "https://argocd.example.com"
+	selfTkn := signedToken(t, time.Hour)
+
+	auth := NewSelfManagedAuth(func(ctx context.Context) (string, string, error) {
+		return endpoint, selfTkn, nil
+	}, &countingAuth{token: "bootstrap"})
+	rejections := 0
+	auth.OnReject(func() { rejections++ })
+
+	assert.False(t, auth.Rejected(selfTkn))
+
+	// a rejected credential is reported so it can be replaced right away
+	assert.True(t, auth.Invalidate(endpoint))
+	assert.Equal(t, 1, rejections)
+	assert.True(t, auth.Rejected(selfTkn))
+
+	// rejections of the bootstrap token don't ask for a new credential
+	auth.Invalidate(endpoint)
+	assert.Equal(t, 1, rejections)
+
+	assert.False(t, auth.Rejected(""))
+}
+
+func TestSelfTokenSourceAppliesDefaults(t *testing.T) {
+	ctx := context.Background()
+	endpoint := "https://argocd.example.com"
+	selfTkn := signedToken(t, time.Hour)
+	selfName := types.NamespacedName{Name: "controller", Namespace: "argo-cd-tokens-system"}
+
+	scheme := runtime.NewScheme()
+	assert.Nil(t, corev1.AddToScheme(scheme))
+	assert.Nil(t, argoprojlabsv2.AddToScheme(scheme))
+	// the self Token leaves its endpoint and Secret key to the controller's defaults
+	reader := fakeclient.NewFakeClientWithScheme(scheme, &argoprojlabsv2.Token{
+		ObjectMeta: metav1.ObjectMeta{Name: selfName.Name, Namespace: selfName.Namespace},
+		Spec: argoprojlabsv2.TokenSpec{
+			Project: "token-controller",
+			Role:    "controller",
+			Output:  argoprojlabsv2.OutputSpec{Secret: argoprojlabsv2.SecretReference{Name: "controller-credential"}},
+		},
+	}, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "controller-credential", Namespace: selfName.Namespace},
+		Data:       map[string][]byte{"token": []byte(selfTkn)},
+	})
+	defaults := argoprojlabsv1.TokenSettings{ArgoCDEndpt: endpoint, SecretKey: "token"}
+
+	selfEndpt, tkn, err := SelfTokenSource(reader, selfName, defaults)(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, endpoint, selfEndpt)
+	assert.Equal(t, selfTkn, tkn)
+
+	// so the controller switches from the bootstrap token to its own credential
+	auth := NewSelfManagedAuth(SelfTokenSource(reader, selfName, defaults), &countingAuth{token: "bootstrap"})
+	tkn, err = auth.Token(ctx, endpoint)
+	assert.Nil(t, err)
+	assert.Equal(t, selfTkn, tkn)
+}
@@ -0,0 +1,33 @@
+package argocd
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SigningKeyKey is the key of Argo CD's argocd-secret holding the key its tokens are signed with
+const SigningKeyKey = "server.secretkey"
+
+// SigningKeyFunc loads the key Argo CD signs tokens with
+type SigningKeyFunc func(ctx context.Context) ([]byte, error)
+
+// SecretSigningKey loads the signing key from the server.secretkey key of a Secret, usually argocd-secret.
+// The Secret is read on every call so a rotated key is picked up without a restart.
+func SecretSigningKey(reader client.Reader, secretName types.NamespacedName) SigningKeyFunc {
+	return func(ctx context.Context) ([]byte, error) {
+		var secret corev1.Secret
+		err := reader.Get(ctx, secretName, &secret)
+		if err != nil {
+			return nil, err
+		}
+		key := secret.Data[SigningKeyKey]
+		if len(key) == 0 {
+			return nil, fmt.Errorf("Secret %s is missing %s", secretName, SigningKeyKey)
+		}
+		return key, nil
+	}
+}
@@ -0,0 +1,161 @@
+package argocd
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// RateLimiters holds one token bucket per Argo CD endpoint, shared by every reconcile calling it
+type RateLimiters struct {
+	qps   float64
+	burst int
+
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+// NewRateLimiters constructs a RateLimiters object. A qps of 0 or less does not limit calls.
+func NewRateLimiters(qps float64, burst int) *RateLimiters {
+	return &RateLimiters{
+		qps:      qps,
+		burst:    burst,
+		limiters: make(map[string]*rate.Limiter),
+	}
+}
+
+// For returns the limiter of the endpoint
+func (r *RateLimiters) For(endpoint string) *rate.Limiter {
+	if r == nil || r.qps <= 0 {
+		return rate.NewLimiter(rate.Inf, 0)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	host := hostOf(endpoint)
+	limiter, ok := r.limiters[host]
+	if !ok {
+		burst := r.burst
+		if burst < 1 {
+			burst = 1
+		}
+		limiter = rate.NewLimiter(rate.Limit(r.qps), burst)
+		r.limiters[host] = limiter
+	}
+	return limiter
+}
+
+// throttledAPI waits for the endpoint's rate limiter before every call and coalesces fetches of the same project.
+// A project fetched within ttl, or still being fetched, is handed out again instead of asking Argo CD once more.
+// Changing a project drops it, so a reconcile always sees its own changes.
+type throttledAPI struct {
+	next    projectAPI
+	limiter *rate.Limiter
+	ttl     time.Duration
+
+	mu       sync.Mutex
+	projects map[string]*projectFetch
+}
+
+// projectFetch is a fetch of a project that is in flight or finished within ttl
+type projectFetch struct {
+	done      chan struct{}
+	project   AppProject
+	err       error
+	fetchedAt time.Time
+}
+
+func newThrottledAPI(next projectAPI, limiter *rate.Limiter, ttl time.Duration) *throttledAPI {
+	return &throttledAPI{
+		next:     next,
+		limiter:  limiter,
+		ttl:      ttl,
+		projects: make(map[string]*projectFetch),
+	}
+}
+
+func (t *throttledAPI) getProject(ctx context.Context, authTkn string, name string) (AppProject, error) {
+	t.mu.Lock()
+	fetch, ok := t.projects[name]
+	if !ok || (!fetch.fetchedAt.IsZero() && time.Since(fetch.fetchedAt) >= t.ttl) {
+		fetch = &projectFetch{done: make(chan struct{})}
+		t.projects[name] = fetch
+		t.mu.Unlock()
+		t.fetch(ctx, authTkn, name, fetch)
+	} else {
+		t.mu.Unlock()
+	}
+
+	select {
+	case <-fetch.done:
+	case <-ctx.Done():
+		return AppProject{}, ctx.Err()
+	}
+	if fetch.err != nil {
+		return AppProject{}, fetch.err
+	}
+	return copyProject(fetch.project), nil
+}
+
+// fetch asks Argo CD for the project. Failures are not kept, the next caller tries again.
+func (t *throttledAPI) fetch(ctx context.Context, authTkn string, name string, fetch *projectFetch) {
+	defer close(fetch.done)
+
+	fetch.err = t.limiter.Wait(ctx)
+	if fetch.err == nil {
+		fetch.project, fetch.err = t.next.getProject(ctx, authTkn, name)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if fetch.err != nil || t.ttl <= 0 {
+		if t.projects[name] == fetch {
+			delete(t.projects, name)
+		}
+		return
+	}
+	fetch.fetchedAt = time.Now()
+}
+
+func (t *throttledAPI) createToken(ctx context.Context, authTkn string, project string, role string, expiresIn int64) (string, error) {
+	defer t.forget(project)
+	if err := t.limiter.Wait(ctx); err != nil {
+		return "", err
+	}
+	return t.next.createToken(ctx, authTkn, project, role, expiresIn)
+}
+
+func (t *throttledAPI) deleteToken(ctx context.Context, authTkn string, project string, role string, iat int64) error {
+	defer t.forget(project)
+	if err := t.limiter.Wait(ctx); err != nil {
+		return err
+	}
+	return t.next.deleteToken(ctx, authTkn, project, role, iat)
+}
+
+func (t *throttledAPI) updateProject(ctx context.Context, authTkn string, project AppProject) (AppProject, error) {
+	defer t.forget(project.Name)
+	if err := t.limiter.Wait(ctx); err != nil {
+		return AppProject{}, err
+	}
+	return t.next.updateProject(ctx, authTkn, project)
+}
+
+// forget drops the fetched project after it was changed
+func (t *throttledAPI) forget(name string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.projects, name)
+}
+
+// copyProject copies the roles of a shared project, which callers append to and filter
+func copyProject(project AppProject) AppProject {
+	project.Spec.Roles = append([]ProjectRole(nil), project.Spec.Roles...)
+	for i := range project.Spec.Roles {
+		project.Spec.Roles[i].JWTTokens = append([]JWTToken(nil), project.Spec.Roles[i].JWTTokens...)
+	}
+	return project
+}
@@ -0,0 +1,151 @@
+package argocd
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+)
+
+// countingAPI counts the calls reaching Argo CD
+type countingAPI struct {
+	mu       sync.Mutex
+	gets     int
+	release  chan struct{}
+	getError error
+}
+
+func (c *countingAPI) getProject(ctx context.Context, authTkn string, name string) (AppProject, error) {
+	c.mu.Lock()
+	c.gets++
+	c.mu.Unlock()
+	if c.release != nil {
+		<-c.release
+	}
+	project := AppProject{Spec: AppProjectSpec{Roles: []ProjectRole{{Name: "ci"}}}}
+	project.Name = name
+	return project, c.getError
+}
+
+func (c *countingAPI) createToken(ctx context.Context, authTkn string, project string, role string, expiresIn int64) (string, error) {
+	return "tkn", nil
+}
+
+func (c *countingAPI) deleteToken(ctx context.Context, authTkn string, project string, role string, iat int64) error {
+	return nil
+}
+
+func (c *countingAPI) updateProject(ctx context.Context, authTkn string, project AppProject) (AppProject, error) {
+	return project, nil
+}
+
+func (c *countingAPI) calls() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.gets
+}
+
+func TestRateLimiters(t *testing.T) {
+	limiters := NewRateLimiters(1, 2)
+
+	// endpoints on the same host share a bucket
+	first := limiters.For("https://argocd.example.com")
+	assert.True(t, first == limiters.For("https://argocd.example.com/"))
+	assert.False(t, first == limiters.For("https://other.example.com"))
+
+	assert.True(t, first.Allow())
+	assert.True(t, first.Allow())
+	assert.False(t, first.Allow())
+
+	// no QPS means no limit
+	assert.Equal(t, rate.Inf, NewRateLimiters(0, 0).For("https://argocd.example.com").Limit())
+	var unset *RateLimiters
+	assert.Equal(t, rate.Inf, unset.For("https://argocd.example.com").Limit())
+}
+
+func TestThrottledAPICoalescesProjects(t *testing.T) {
+	next := &countingAPI{}
+	api := newThrottledAPI(next, rate.NewLimiter(rate.Inf, 0), time.Hour)
+	ctx := context.Background()
+
+	project, err := api.getProject(ctx, "tkn", "default")
+	assert.Nil(t, err)
+	assert.Equal(t, "default", project.Name)
+	_, err = api.getProject(ctx, "tkn", "default")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, next.calls())
+
+	// callers get a copy they may change
+	project.Spec.Roles[0].Name = "changed"
+	project, _ = api.getProject(ctx, "tkn", "default")
+	assert.Equal(t, "ci", project.Spec.Roles[0].Name)
+
+	_, err = api.getProject(ctx, "tkn", "other")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, next.calls())
+
+	// changing the project drops it
+	_, err = api.createToken(ctx, "tkn", "default", "ci", 0)
+	assert.Nil(t, err)
+	_, err = api.getProject(ctx, "tkn", "default")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, next.calls())
+
+	// failures are not kept
+	next.getError = errors.New("boom")
+	api.forget("default")
+	_, err = api.getProject(ctx, "tkn", "default")
+	assert.NotNil(t, err)
+	next.getError = nil
+	_, err = api.getProject(ctx, "tkn", "default")
+	assert.Nil(t, err)
+	assert.Equal(t, 5, next.calls())
+}
+
+func TestThrottledAPIJoinsFetchInFlight(t *testing.T) {
+	next := &countingAPI{release: make(chan struct{})}
+	api := newThrottledAPI(next, rate.NewLimiter(rate.Inf, 0), 0)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := api.getProject(ctx, "tkn", "default")
+			assert.Nil(t, err)
+		}()
+	}
+	// wait for the first fetch to reach Argo CD before letting it finish
+	for next.calls() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(next.release)
+	wg.Wait()
+	assert.Equal(t, 1, next.calls())
+
+	// without a TTL the next fetch asks Argo CD again
+	_, err := api.getProject(ctx, "tkn", "default")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, next.calls())
+}
+
+func TestThrottledAPIWaitsForLimiter(t *testing.T) {
+	next := &countingAPI{}
+	api := newThrottledAPI(next, rate.NewLimiter(rate.Every(time.Hour), 1), 0)
+
+	_, err := api.getProject(context.Background(), "tkn", "default")
+	assert.Nil(t, err)
+
+	// the bucket is empty, the call gives up with its context
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = api.createToken(ctx, "tkn", "default", "ci", 0)
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, next.calls())
+}
@@ -0,0 +1,58 @@
+package jwt
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/clock"
+)
+
+// maxSkewPercent caps the skew tolerance at a share of a token's lifetime, so short lived tokens
+// aren't considered expired as soon as they are issued
+const maxSkewPercent = 10
+
+// Expiry makes expiry decisions on an injectable clock. Since the controller's clock may be off from Argo CD's,
+// tokens are treated as expired Skew before their exp so they are never used after Argo CD rejects them.
+type Expiry struct {
+	// Clock tells the time, the real clock is used if it is nil
+	Clock clock.Clock
+	// Skew is how far the controller's clock may be off from Argo CD's
+	Skew time.Duration
+	// RenewPercent treats tokens as expired once they used up this much of their lifetime, 0 at their exp
+	RenewPercent int
+}
+
+// Now returns the current time of the clock
+func (e Expiry) Now() time.Time {
+	if e.Clock == nil {
+		return time.Now()
+	}
+	return e.Clock.Now()
+}
+
+// Expired reports whether the token has expired, allowing for skew
+func (e Expiry) Expired(claims *Claims) bool {
+	return claims.Expired(e.skewedNow(claims))
+}
+
+// Remaining returns how long until the token is considered expired, allowing for skew
+func (e Expiry) Remaining(claims *Claims) time.Duration {
+	return claims.Remaining(e.skewedNow(claims))
+}
+
+// Elapsed returns the fraction of its lifetime the token has used up, allowing for skew
+func (e Expiry) Elapsed(claims *Claims) float64 {
+	return claims.Elapsed(e.skewedNow(claims))
+}
+
+// skewedNow returns the current time moved ahead by the skew tolerance applying to the token, and by the rest of its
+// lifetime after RenewPercent
+func (e Expiry) skewedNow(claims *Claims) time.Time {
+	skew := e.Skew
+	if maxSkew := claims.Lifetime() * maxSkewPercent / 100; skew > maxSkew {
+		skew = maxSkew
+	}
+	if e.RenewPercent > 0 && e.RenewPercent < 100 {
+		skew += claims.Lifetime() * time.Duration(100-e.RenewPercent) / 100
+	}
+	return e.Now().Add(skew)
+}
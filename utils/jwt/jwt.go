@@ -3,94 +3,174 @@ package jwt
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	jwt "github.com/dgrijalva/jwt-go"
 )
 
-// TokenExpired returns true if the token provided is expired
-func TokenExpired(token string) (bool, error) {
+// Issuer is the iss claim of tokens issued by Argo CD
+const Issuer = "argocd"
+
+// Claims are the claims of a token issued by Argo CD
+type Claims struct {
+	// IssuedAt identifies the token within its project role
+	IssuedAt int64
+	// ExpiresAt is 0 for tokens that never expire
+	ExpiresAt int64
+	NotBefore int64
+	Subject   string
+	Issuer    string
+	ID        string
+}
+
+// ParseClaims parses the claims of a token without verifying it. Tokens without iat are rejected
+// since Argo CD identifies tokens by it.
+func ParseClaims(token string) (*Claims, error) {
 
-	jwtTkn, err := jwt.Parse(token, nil)
-	if jwtTkn == nil {
-		//fmt.Println(err)
-		return true, err
+	mapClaims := jwt.MapClaims{}
+	_, _, err := new(jwt.Parser).ParseUnverified(token, mapClaims)
+	if err != nil {
+		return nil, err
 	}
 
-	if claims, ok := jwtTkn.Claims.(jwt.MapClaims); ok {
-		tknExp, err := getExpiredAt(claims)
-		if err != nil {
-			//fmt.Println(err)
-			return true, err
-		}
-		currTime := time.Now().Unix()
-		if tknExp <= currTime {
-			return true, nil
-		}
-	} else {
-		//fmt.Println(err)
-		return true, err
+	return claimsFrom(mapClaims)
+}
+
+// claimsFrom types the claims of a parsed token
+func claimsFrom(m jwt.MapClaims) (*Claims, error) {
+
+	var claims Claims
+	var err error
+
+	if claims.IssuedAt, err = numericClaim(m, "iat"); err != nil {
+		return nil, err
+	}
+	if claims.IssuedAt == 0 {
+		return nil, fmt.Errorf("token has no iat")
+	}
+	if claims.ExpiresAt, err = numericClaim(m, "exp"); err != nil {
+		return nil, err
+	}
+	if claims.NotBefore, err = numericClaim(m, "nbf"); err != nil {
+		return nil, err
 	}
 
-	return false, nil
+	claims.Subject, _ = m["sub"].(string)
+	claims.Issuer, _ = m["iss"].(string)
+	claims.ID, _ = m["jti"].(string)
 
+	return &claims, nil
 }
 
-// TimeTillExpire returns the duration of time left till the token's expirationn
-func TimeTillExpire(token string) int64 {
+// Expires reports whether the token expires at all
+func (c *Claims) Expires() bool {
+	return c.ExpiresAt != 0
+}
 
-	jwtTkn, _ := jwt.Parse(token, nil)
+// Expired reports whether the token has expired at now
+func (c *Claims) Expired(now time.Time) bool {
+	return c.Expires() && c.ExpiresAt <= now.Unix()
+}
 
-	if claims, ok := jwtTkn.Claims.(jwt.MapClaims); ok {
-		tknExp, _ := getExpiredAt(claims)
-		currTime := time.Now().Unix()
-		timeTillExpire := tknExp - currTime
-		if timeTillExpire <= 0 {
-			return 0
-		}
-		return timeTillExpire
+// Lifetime returns how long the token is valid from when it was issued, or 0 if it never expires
+func (c *Claims) Lifetime() time.Duration {
+	if !c.Expires() {
+		return 0
 	}
+	return time.Duration(c.ExpiresAt-c.IssuedAt) * time.Second
+}
 
-	return 0
+// Remaining returns how long until the token expires, or 0 if it never expires or already expired
+func (c *Claims) Remaining(now time.Time) time.Duration {
+	if !c.Expires() {
+		return 0
+	}
+	remaining := time.Unix(c.ExpiresAt, 0).Sub(now)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
 }
 
-// ReturnIAT will provide the issued for a token
-func ReturnIAT(token string) int64 {
+// Elapsed returns the fraction of its lifetime the token has used up at now, or 0 if it never expires
+func (c *Claims) Elapsed(now time.Time) float64 {
+	lifetime := c.Lifetime()
+	if lifetime <= 0 {
+		return 0
+	}
+	elapsed := float64(now.Sub(time.Unix(c.IssuedAt, 0))) / float64(lifetime)
+	if elapsed < 0 {
+		return 0
+	}
+	return elapsed
+}
+
+// ProjectRole extracts the project and role from a sub of the form proj:<project>:<role>
+func (c *Claims) ProjectRole() (string, string, error) {
+	parts := strings.SplitN(c.Subject, ":", 3)
+	if len(parts) != 3 || parts[0] != "proj" || parts[1] == "" || parts[2] == "" {
+		return "", "", fmt.Errorf("sub %q is not a project role", c.Subject)
+	}
+	return parts[1], parts[2], nil
+}
+
+// VerifyToken checks that the token was issued by Argo CD for the role of the project. If the key Argo CD
+// signs tokens with is given, the signature is verified as well. Whether the token expired is not checked.
+func VerifyToken(token string, project string, role string, signingKey []byte) error {
 
-	jwtTkn, _ := jwt.Parse(token, nil)
+	parser := &jwt.Parser{SkipClaimsValidation: true}
+	mapClaims := jwt.MapClaims{}
+
+	if signingKey == nil {
+		_, _, err := parser.ParseUnverified(token, mapClaims)
+		if err != nil {
+			return err
+		}
+	} else {
+		_, err := parser.ParseWithClaims(token, mapClaims, func(jwtTkn *jwt.Token) (interface{}, error) {
+			if _, ok := jwtTkn.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, fmt.Errorf("unexpected signing method %v", jwtTkn.Header["alg"])
+			}
+			return signingKey, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
 
-	if claims, ok := jwtTkn.Claims.(jwt.MapClaims); ok {
-		tknIat, _ := getIssuedAt(claims)
-		return tknIat
+	claims, err := claimsFrom(mapClaims)
+	if err != nil {
+		return err
 	}
 
-	return 0
-}
+	if claims.Issuer != Issuer {
+		return fmt.Errorf("token was issued by %q instead of %q", claims.Issuer, Issuer)
+	}
 
-// getIssuedAt comes from argo-cd/util/jwt/jwt.go
-func getIssuedAt(m jwt.MapClaims) (int64, error) {
-	switch iat := m["iat"].(type) {
-	case float64:
-		return int64(iat), nil
-	case json.Number:
-		return iat.Int64()
-	case int64:
-		return iat, nil
-	default:
-		return 0, fmt.Errorf("iat '%v' is not a number", iat)
+	tknProject, tknRole, err := claims.ProjectRole()
+	if err != nil {
+		return err
 	}
+	if tknProject != project || tknRole != role {
+		return fmt.Errorf("token was issued for %q instead of %q", claims.Subject, fmt.Sprintf("proj:%s:%s", project, role))
+	}
+
+	return nil
 }
 
-// getExpiredAt comes from argo-cd/util/jwt/jwt.go
-func getExpiredAt(m jwt.MapClaims) (int64, error) {
-	switch exp := m["exp"].(type) {
+// numericClaim returns a numeric claim, or 0 if the token doesn't have it. Adapted from argo-cd/util/jwt/jwt.go
+func numericClaim(m jwt.MapClaims, name string) (int64, error) {
+	switch value := m[name].(type) {
+	case nil:
+		return 0, nil
 	case float64:
-		return int64(exp), nil
+		return int64(value), nil
 	case json.Number:
-		return exp.Int64()
+		return value.Int64()
 	case int64:
-		return exp, nil
+		return value, nil
 	default:
-		return 0, fmt.Errorf("exp '%v' is not a number", exp)
+		return 0, fmt.Errorf("%s '%v' is not a number", name, value)
 	}
 }
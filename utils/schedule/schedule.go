@@ -0,0 +1,146 @@
+package schedule
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	argoprojlabsv1 "github.com/argoproj-labs/argo-cd-tokens/api/v1"
+)
+
+const (
+	// DefaultWindow is how long a rotation window stays open if the schedule doesn't say
+	DefaultWindow = time.Hour
+
+	// maxOpenSteps bounds the search for the next open time through overlapping blackouts
+	maxOpenSteps = 100
+	// maxPlanSteps bounds how many windows ahead a rotation is planned, the plan is refreshed when it comes due
+	maxPlanSteps = 1000
+)
+
+// Schedule tells when tokens may be rotated: inside a rotation window and outside every blackout
+type Schedule struct {
+	windows   cron.Schedule
+	window    time.Duration
+	blackouts []blackout
+}
+
+type blackout struct {
+	starts   cron.Schedule
+	duration time.Duration
+}
+
+// Parse builds the Schedule of a Token's spec.rotationSchedule
+func Parse(spec argoprojlabsv1.RotationSchedule) (*Schedule, error) {
+	timeZone := spec.TimeZone
+	if timeZone == "" {
+		timeZone = "UTC"
+	}
+	if _, err := time.LoadLocation(timeZone); err != nil {
+		return nil, fmt.Errorf("Invalid rotation schedule time zone %s: %v", timeZone, err)
+	}
+
+	windows, err := parseCron(spec.Cron, timeZone)
+	if err != nil {
+		return nil, err
+	}
+	s := &Schedule{
+		windows: windows,
+		window:  time.Duration(spec.Window) * time.Second,
+	}
+	if s.window <= 0 {
+		s.window = DefaultWindow
+	}
+
+	for _, b := range spec.Blackouts {
+		starts, err := parseCron(b.Cron, timeZone)
+		if err != nil {
+			return nil, err
+		}
+		if b.Duration <= 0 {
+			return nil, fmt.Errorf("Blackout %s needs a positive duration", b.Cron)
+		}
+		s.blackouts = append(s.blackouts, blackout{starts: starts, duration: time.Duration(b.Duration) * time.Second})
+	}
+
+	return s, nil
+}
+
+func parseCron(expr string, timeZone string) (cron.Schedule, error) {
+	schedule, err := cron.ParseStandard("CRON_TZ=" + timeZone + " " + expr)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid cron expression %s: %v", expr, err)
+	}
+	return schedule, nil
+}
+
+// Open reports whether a token may be rotated at t
+func (s *Schedule) Open(t time.Time) bool {
+	if _, ok := s.windowEnd(t); !ok {
+		return false
+	}
+	_, blocked := s.blackoutEnd(t)
+	return !blocked
+}
+
+// NextOpen returns the earliest time from t on at which a token may be rotated, or the zero time if none was found
+func (s *Schedule) NextOpen(t time.Time) time.Time {
+	for i := 0; i < maxOpenSteps && !t.IsZero(); i++ {
+		if _, ok := s.windowEnd(t); !ok {
+			t = s.windows.Next(t)
+			continue
+		}
+		if end, blocked := s.blackoutEnd(t); blocked {
+			t = end
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// Plan returns when to rotate a token that has to be replaced by deadline: at the opening of the last window
+// before the deadline, so the token is rotated as rarely as possible. It returns the zero time if no window
+// opens before the deadline.
+func (s *Schedule) Plan(now time.Time, deadline time.Time) time.Time {
+	planned := s.NextOpen(now)
+	if planned.IsZero() || !planned.Before(deadline) {
+		return time.Time{}
+	}
+
+	for i := 0; i < maxPlanSteps; i++ {
+		end, _ := s.windowEnd(planned)
+		next := s.NextOpen(end)
+		if next.IsZero() || !next.Before(deadline) {
+			break
+		}
+		planned = next
+	}
+	return planned
+}
+
+// windowEnd returns when the rotation window open at t closes, if one is open
+func (s *Schedule) windowEnd(t time.Time) (time.Time, bool) {
+	// the earliest window still open at t started after t-window
+	start := s.windows.Next(t.Add(-s.window))
+	if start.IsZero() || start.After(t) {
+		return time.Time{}, false
+	}
+	return start.Add(s.window), true
+}
+
+// blackoutEnd returns when the blackouts in effect at t are over, if any is
+func (s *Schedule) blackoutEnd(t time.Time) (time.Time, bool) {
+	var end time.Time
+	for _, b := range s.blackouts {
+		start := b.starts.Next(t.Add(-b.duration))
+		if start.IsZero() || start.After(t) {
+			continue
+		}
+		if bEnd := start.Add(b.duration); bEnd.After(end) {
+			end = bEnd
+		}
+	}
+	return end, !end.IsZero()
+}
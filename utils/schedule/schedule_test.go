@@ -0,0 +1,64 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	argoprojlabsv1 "github.com/argoproj-labs/argo-cd-tokens/api/v1"
+)
+
+func at(t *testing.T, value string) time.Time {
+	parsed, err := time.Parse(time.RFC3339, value)
+	assert.Nil(t, err)
+	return parsed
+}
+
+func TestSchedule(t *testing.T) {
+	// rotate on weekdays between 02:00 and 04:00 Berlin time, but not on the first of the month
+	s, err := Parse(argoprojlabsv1.RotationSchedule{
+		Cron:      "0 2 * * 1-5",
+		TimeZone:  "Europe/Berlin",
+		Window:    7200,
+		Blackouts: []argoprojlabsv1.BlackoutWindow{{Cron: "0 0 1 * *", Duration: 24 * 3600}},
+	})
+	assert.Nil(t, err)
+
+	// Tuesday 2019-08-06, Berlin is UTC+2
+	assert.True(t, s.Open(at(t, "2019-08-06T00:00:00Z")))
+	assert.True(t, s.Open(at(t, "2019-08-06T01:59:59Z")))
+	assert.False(t, s.Open(at(t, "2019-08-06T02:00:00Z")))
+	assert.False(t, s.Open(at(t, "2019-08-05T23:59:59Z")))
+	// Sunday
+	assert.False(t, s.Open(at(t, "2019-08-04T00:30:00Z")))
+	// Tuesday the first of October is blacked out
+	assert.False(t, s.Open(at(t, "2019-10-01T00:30:00Z")))
+
+	assert.Equal(t, at(t, "2019-08-06T00:30:00Z"), s.NextOpen(at(t, "2019-08-06T00:30:00Z")))
+	assert.Equal(t, at(t, "2019-08-07T00:00:00Z"), s.NextOpen(at(t, "2019-08-06T03:00:00Z")))
+	assert.Equal(t, at(t, "2019-08-05T00:00:00Z"), s.NextOpen(at(t, "2019-08-03T12:00:00Z")))
+	assert.Equal(t, at(t, "2019-10-02T00:00:00Z"), s.NextOpen(at(t, "2019-09-30T03:00:00Z")))
+
+	// the last window before Friday noon opens on Friday
+	assert.Equal(t, at(t, "2019-08-09T00:00:00Z"), s.Plan(at(t, "2019-08-06T12:00:00Z"), at(t, "2019-08-09T12:00:00Z")))
+	// inside the last window the token is rotated right away
+	assert.Equal(t, at(t, "2019-08-09T00:30:00Z"), s.Plan(at(t, "2019-08-09T00:30:00Z"), at(t, "2019-08-09T12:00:00Z")))
+	// no window opens before Sunday noon
+	assert.True(t, s.Plan(at(t, "2019-08-10T00:00:00Z"), at(t, "2019-08-11T12:00:00Z")).IsZero())
+}
+
+func TestParse(t *testing.T) {
+	s, err := Parse(argoprojlabsv1.RotationSchedule{Cron: "0 3 * * *"})
+	assert.Nil(t, err)
+	assert.Equal(t, DefaultWindow, s.window)
+	assert.True(t, s.Open(at(t, "2019-08-06T03:59:59Z")))
+	assert.False(t, s.Open(at(t, "2019-08-06T04:00:00Z")))
+
+	_, err = Parse(argoprojlabsv1.RotationSchedule{Cron: "every night"})
+	assert.NotNil(t, err)
+	_, err = Parse(argoprojlabsv1.RotationSchedule{Cron: "0 3 * * *", TimeZone: "Mars/Olympus_Mons"})
+	assert.NotNil(t, err)
+	_, err = Parse(argoprojlabsv1.RotationSchedule{Cron: "0 3 * * *", Blackouts: []argoprojlabsv1.BlackoutWindow{{Cron: "0 0 * * *"}}})
+	assert.NotNil(t, err)
+}
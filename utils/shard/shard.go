@@ -0,0 +1,50 @@
+package shard
+
+import (
+	"fmt"
+	"hash/fnv"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Shard is one of Count shards that Tokens are split across by a consistent hash of their UID, so every Token is
+// reconciled by exactly one controller replica. The zero Shard owns every Token.
+type Shard struct {
+	ID    int
+	Count int
+}
+
+// Validate checks that the shard is one of its Count shards
+func (s Shard) Validate() error {
+	if s.Count < 0 || s.ID < 0 || (s.Count > 0 && s.ID >= s.Count) || (s.Count == 0 && s.ID != 0) {
+		return fmt.Errorf("Shard %d is not one of %d shards", s.ID, s.Count)
+	}
+	return nil
+}
+
+// Owns reports whether the Token with the given UID belongs to the shard
+func (s Shard) Owns(uid types.UID) bool {
+	if s.Count <= 1 {
+		return true
+	}
+	return Of(uid, s.Count) == s.ID
+}
+
+// Of returns the shard out of count a Token with the given UID belongs to. Growing count by one only moves
+// a 1/count share of the Tokens to the new shard.
+func Of(uid types.UID, count int) int {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(uid))
+	return jumpHash(h.Sum64(), count)
+}
+
+// jumpHash is Lamping and Veach's jump consistent hash
+func jumpHash(key uint64, buckets int) int {
+	var b, j int64 = -1, 0
+	for j < int64(buckets) {
+		b = j
+		key = key*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+	}
+	return int(b)
+}
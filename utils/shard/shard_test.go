@@ -0,0 +1,54 @@
+package shard
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestShard(t *testing.T) {
+	uids := make([]types.UID, 1000)
+	for i := range uids {
+		uids[i] = types.UID(fmt.Sprintf("5f6e2c1a-0000-4000-8000-%012d", i))
+	}
+
+	// every Token is owned by exactly one of the shards, and the shards are about even
+	for _, uid := range uids {
+		owners := 0
+		for id := 0; id < 4; id++ {
+			if (Shard{ID: id, Count: 4}).Owns(uid) {
+				owners++
+			}
+		}
+		assert.Equal(t, 1, owners)
+	}
+	counts := make([]int, 4)
+	for _, uid := range uids {
+		counts[Of(uid, 4)]++
+	}
+	for _, count := range counts {
+		assert.InDelta(t, 250, count, 60)
+	}
+
+	// adding a fifth shard only moves Tokens to it
+	moved := 0
+	for _, uid := range uids {
+		if before, after := Of(uid, 4), Of(uid, 5); before != after {
+			assert.Equal(t, 4, after)
+			moved++
+		}
+	}
+	assert.InDelta(t, 200, moved, 60)
+
+	assert.True(t, Shard{}.Owns(uids[0]))
+}
+
+func TestValidate(t *testing.T) {
+	assert.Nil(t, Shard{}.Validate())
+	assert.Nil(t, Shard{ID: 2, Count: 3}.Validate())
+	assert.NotNil(t, Shard{ID: 3, Count: 3}.Validate())
+	assert.NotNil(t, Shard{ID: -1, Count: 3}.Validate())
+	assert.NotNil(t, Shard{ID: 1}.Validate())
+}